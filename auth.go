@@ -0,0 +1,341 @@
+package go_requests
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Authenticator 认证方式, Authorize在发送前为请求添加认证信息
+// 收到401响应时调用Challenge处理WWW-Authenticate质询, 返回true时重新调用Authorize并重发一次请求
+type Authenticator interface {
+	Authorize(r *http.Request) error
+	Challenge(r *http.Request, res *http.Response) bool
+}
+
+// SchemeAuthenticator 声明认证方案(如 "Basic"、"Digest")的Authenticator, AuthChain按服务器质询中的认证方案选用
+type SchemeAuthenticator interface {
+	Authenticator
+	Scheme() string
+}
+
+// BasicAuth HTTP基础认证
+type BasicAuth struct {
+	Username string `json:"username"` // 用户名
+	Password string `json:"password"` // 密码
+}
+
+func (a *BasicAuth) Authorize(r *http.Request) error {
+	r.SetBasicAuth(a.Username, a.Password)
+	return nil
+}
+
+// Challenge 用户名密码不会变化, 不重新认证
+func (a *BasicAuth) Challenge(r *http.Request, res *http.Response) bool {
+	return false
+}
+
+func (a *BasicAuth) Scheme() string {
+	return "Basic"
+}
+
+// BearerAuth 固定Bearer令牌认证, 需要自动获取及刷新令牌时请使用OAuth2
+type BearerAuth struct {
+	Token string `json:"token"` // 令牌
+}
+
+func (a *BearerAuth) Authorize(r *http.Request) error {
+	r.Header.Set("Authorization", "Bearer "+a.Token)
+	return nil
+}
+
+func (a *BearerAuth) Challenge(r *http.Request, res *http.Response) bool {
+	return false
+}
+
+func (a *BearerAuth) Scheme() string {
+	return "Bearer"
+}
+
+// APIKeyAuth API Key认证, 通过请求头、Query参数或Cookie发送
+type APIKeyAuth struct {
+	Name  string `json:"name"`  // 请求头、Query参数或Cookie名称，如 X-Api-Key
+	Value string `json:"value"` // API Key
+	In    string `json:"in"`    // 发送位置，header、query或cookie，默认header
+}
+
+func (a *APIKeyAuth) Authorize(r *http.Request) error {
+	switch strings.ToLower(a.In) {
+	case "", "header":
+		r.Header.Set(a.Name, a.Value)
+	case "query":
+		query := r.URL.Query()
+		query.Set(a.Name, a.Value)
+		r.URL.RawQuery = query.Encode()
+	case "cookie":
+		r.AddCookie(&http.Cookie{Name: a.Name, Value: a.Value})
+	default:
+		return fmt.Errorf("不支持的API Key位置 \"%s\", 仅支持header、query及cookie", a.In)
+	}
+	return nil
+}
+
+func (a *APIKeyAuth) Challenge(r *http.Request, res *http.Response) bool {
+	return false
+}
+
+// AuthChain 组合多种认证方式, 按服务器WWW-Authenticate质询中的认证方案选用
+// 未声明认证方案的Authenticator(如APIKeyAuth)总是使用; 首次请求某主机时不发送带认证方案的认证信息,
+// 收到质询后按Authenticators中的顺序选用服务器支持的第一种认证方式, 该主机的后续请求直接使用
+type AuthChain struct {
+	Authenticators []Authenticator // 认证方式, 靠前的优先
+
+	mu       sync.Mutex
+	selected map[string]SchemeAuthenticator // 各主机选用的认证方式
+}
+
+func NewAuthChain(authenticators ...Authenticator) *AuthChain {
+	return &AuthChain{Authenticators: authenticators}
+}
+
+func (c *AuthChain) Authorize(r *http.Request) error {
+	for _, auth := range c.Authenticators {
+		if _, ok := auth.(SchemeAuthenticator); ok {
+			continue
+		}
+		if err := auth.Authorize(r); err != nil {
+			return err
+		}
+	}
+	c.mu.Lock()
+	auth := c.selected[r.URL.Host]
+	c.mu.Unlock()
+	if auth == nil {
+		return nil
+	}
+	return auth.Authorize(r)
+}
+
+// Challenge 选用服务器支持的认证方式, 已选用的认证方式由其自身处理质询(如Digest nonce过期、OAuth2令牌刷新)
+func (c *AuthChain) Challenge(r *http.Request, res *http.Response) bool {
+	if res.StatusCode != http.StatusUnauthorized {
+		return false
+	}
+	var schemes []string
+	for _, challenge := range parseChallenges(res.Header.Values("WWW-Authenticate")) {
+		schemes = append(schemes, strings.ToLower(challenge.scheme))
+	}
+	for _, auth := range c.Authenticators {
+		schemeAuth, ok := auth.(SchemeAuthenticator)
+		if !ok || indexOf(schemes, strings.ToLower(schemeAuth.Scheme())) < 0 {
+			continue
+		}
+		c.mu.Lock()
+		previous := c.selected[r.URL.Host]
+		if c.selected == nil {
+			c.selected = map[string]SchemeAuthenticator{}
+		}
+		c.selected[r.URL.Host] = schemeAuth
+		c.mu.Unlock()
+		if schemeAuth.Challenge(r, res) {
+			return true
+		}
+		return previous != schemeAuth // 新选用的认证方式需要重新认证
+	}
+	return false
+}
+
+// 请求使用的认证方式, 优先级: Authenticator > OAuth2 > DigestAuth
+func (req *Request) authenticator() Authenticator {
+	switch {
+	case req.Authenticator != nil:
+		return req.Authenticator
+	case req.OAuth2 != nil:
+		return req.OAuth2
+	case req.DigestAuth != nil:
+		return req.DigestAuth
+	}
+	return nil
+}
+
+// 发送请求, 设置认证方式时为请求添加认证信息, 收到401质询时重新认证并重发一次
+// 已手动设置Authorization请求头时不处理, 设置Signer时认证后重新签名
+func (req *Request) doAuth(client *http.Client, r *http.Request) (*http.Response, error) {
+	auth := req.authenticator()
+	if auth == nil || req.hasHeader("Authorization") {
+		return client.Do(r)
+	}
+	if err := req.authorize(auth, r); err != nil {
+		return nil, req.newError(ErrAuth, err)
+	}
+	if err := req.sign(r); err != nil {
+		return nil, req.newError(ErrBuildBody, err)
+	}
+	res, err := client.Do(r)
+	if err != nil || res.StatusCode != http.StatusUnauthorized || !auth.Challenge(r, res) {
+		return res, err
+	}
+	replay, ok := rewindRequest(r)
+	if !ok {
+		return res, nil
+	}
+	if err := req.authorize(auth, replay); err == nil {
+		err = req.sign(replay)
+	}
+	if err != nil {
+		if replay.Body != nil {
+			replay.Body.Close()
+		}
+		return res, nil
+	}
+	_, _ = io.Copy(ioutil.Discard, res.Body)
+	res.Body.Close()
+	return client.Do(replay)
+}
+
+// 使用认证方式为请求添加认证信息, 并记录其添加或修改的请求头, 重定向到其他主机时删除这些请求头
+func (req *Request) authorize(auth Authenticator, r *http.Request) error {
+	before := r.Header.Clone()
+	if err := auth.Authorize(r); err != nil {
+		return err
+	}
+	for key, values := range r.Header {
+		if equalStrings(before[key], values) {
+			continue
+		}
+		if req.authHeaders == nil {
+			req.authHeaders = map[string]bool{}
+		}
+		req.authHeaders[key] = true
+	}
+	return nil
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// 复制请求并重新获取请求数据, 请求数据无法重复读取时返回false
+func rewindRequest(r *http.Request) (*http.Request, bool) {
+	replay := r.Clone(r.Context())
+	if r.Body == nil || r.Body == http.NoBody {
+		return replay, true
+	}
+	if r.GetBody == nil {
+		return nil, false
+	}
+	body, err := r.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	replay.Body = body
+	return replay, true
+}
+
+// 认证质询, 包含认证方案及参数
+type authChallenge struct {
+	scheme string
+	params map[string]string
+}
+
+// 解析WWW-Authenticate或Authorization头信息, 一个头信息中可能包含多个质询
+func parseChallenges(values []string) []authChallenge {
+	var challenges []authChallenge
+	for _, value := range values {
+		var params map[string]string
+		rest := value
+		for {
+			rest = strings.TrimLeft(rest, " \t,")
+			if rest == "" {
+				break
+			}
+			var token string
+			token, rest = readToken(rest)
+			rest = strings.TrimLeft(rest, " \t")
+			if strings.HasPrefix(rest, "=") {
+				var paramValue string
+				paramValue, rest = readParamValue(strings.TrimLeft(rest[1:], " \t"))
+				if params != nil {
+					params[strings.ToLower(token)] = paramValue
+				}
+				continue
+			}
+			// 不带=的token为新的认证方案
+			params = map[string]string{}
+			challenges = append(challenges, authChallenge{scheme: token, params: params})
+		}
+	}
+	return challenges
+}
+
+// 解析指定认证方案的质询参数
+func parseAuthParams(values []string, scheme string) []map[string]string {
+	var list []map[string]string
+	for _, challenge := range parseChallenges(values) {
+		if strings.EqualFold(challenge.scheme, scheme) {
+			list = append(list, challenge.params)
+		}
+	}
+	return list
+}
+
+func readToken(s string) (string, string) {
+	i := strings.IndexAny(s, " \t,=")
+	if i < 0 {
+		return s, ""
+	}
+	if i == 0 {
+		i = 1
+	}
+	return s[:i], s[i:]
+}
+
+// 读取参数值, 支持带转义的引号字符串
+func readParamValue(s string) (string, string) {
+	if !strings.HasPrefix(s, `"`) {
+		i := strings.IndexAny(s, " \t,")
+		if i < 0 {
+			return s, ""
+		}
+		return s[:i], s[i:]
+	}
+	var builder strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) {
+				i++
+				builder.WriteByte(s[i])
+			}
+		case '"':
+			return builder.String(), s[i+1:]
+		default:
+			builder.WriteByte(s[i])
+		}
+	}
+	return builder.String(), ""
+}
+
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func indexOf(list []string, item string) int {
+	for i, value := range list {
+		if value == item {
+			return i
+		}
+	}
+	return -1
+}
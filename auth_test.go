@@ -0,0 +1,162 @@
+package go_requests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// 返回收到的Authorization及API Key的服务
+func newAuthServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("X-Api-Key") + r.URL.Query().Get("api_key")
+		if cookie, err := r.Cookie("api_key"); err == nil {
+			key += cookie.Value
+		}
+		_, _ = w.Write([]byte(r.Header.Get("Authorization") + "|" + key))
+	}))
+}
+
+// 测试内置Basic、Bearer及API Key认证
+func TestBuiltinAuthenticators(t *testing.T) {
+	server := newAuthServer()
+	defer server.Close()
+
+	cases := []struct {
+		auth     Authenticator
+		expected string
+	}{
+		{&BasicAuth{Username: "kevin", Password: "123456"}, "Basic a2V2aW46MTIzNDU2|"},
+		{&BearerAuth{Token: "abc"}, "Bearer abc|"},
+		{&APIKeyAuth{Name: "X-Api-Key", Value: "k1"}, "|k1"},
+		{&APIKeyAuth{Name: "api_key", Value: "k2", In: "query"}, "|k2"},
+		{&APIKeyAuth{Name: "api_key", Value: "k3", In: "cookie"}, "|k3"},
+	}
+	for _, c := range cases {
+		resp, err := NewRequest("GET", server.URL+"?a=1").SetAuthenticator(c.auth).Do()
+		if err != nil {
+			t.Fatalf("请求失败: %s", err)
+		}
+		if resp.Text != c.expected {
+			t.Errorf("期望 %s, 实际 %s", c.expected, resp.Text)
+		}
+	}
+
+	_, err := NewRequest("GET", server.URL).SetAuthenticator(&APIKeyAuth{Name: "k", Value: "v", In: "body"}).Do()
+	if err == nil {
+		t.Error("期望不支持的API Key位置错误")
+	}
+}
+
+// 测试重定向到其他主机时不携带API Key
+func TestAPIKeyAuthRedirect(t *testing.T) {
+	other := newAuthServer()
+	defer other.Close()
+	otherUrl := strings.Replace(other.URL, "127.0.0.1", "localhost", 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, otherUrl, http.StatusFound)
+	}))
+	defer server.Close()
+
+	for _, in := range []string{"header", "query", "cookie"} {
+		auth := &APIKeyAuth{Name: "X-Api-Key", Value: "secret", In: in}
+		if in != "header" {
+			auth.Name = "api_key"
+		}
+		resp, err := NewRequest("GET", server.URL).SetAuthenticator(auth).Do()
+		if err != nil {
+			t.Fatalf("%s: 请求失败: %s", in, err)
+		}
+		if resp.Text != "|" {
+			t.Errorf("%s: 重定向到其他主机时不应携带API Key, 实际 %s", in, resp.Text)
+		}
+	}
+}
+
+// 记录质询次数的认证方式, 首次请求返回401后重新认证
+type countingAuth struct {
+	mu         sync.Mutex
+	challenges int
+}
+
+func (a *countingAuth) Authorize(r *http.Request) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.challenges > 0 {
+		r.Header.Set("Authorization", "Custom ok")
+	}
+	return nil
+}
+
+func (a *countingAuth) Challenge(r *http.Request, res *http.Response) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.challenges++
+	return true
+}
+
+// 测试自定义认证方式在会话中使用, 收到401时只重发一次
+func TestCustomAuthenticator(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Custom ok" {
+			w.Header().Set("WWW-Authenticate", "Custom")
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	auth := &countingAuth{}
+	session := NewSession(nil).SetAuthenticator(auth)
+	for i := 0; i < 2; i++ {
+		if resp := session.Get(server.URL, nil); resp.StatusCode != http.StatusOK {
+			t.Fatalf("期望200, 实际 %d", resp.StatusCode)
+		}
+	}
+	if auth.challenges != 1 {
+		t.Errorf("期望1次质询, 实际 %d", auth.challenges)
+	}
+}
+
+// 测试AuthChain按服务器质询选用认证方式
+func TestAuthChain(t *testing.T) {
+	digestServer := newDigestServer("SHA-256", "auth")
+	defer digestServer.Close()
+	basicServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "kevin" || password != "secret" || r.Header.Get("X-Api-Key") != "k" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer basicServer.Close()
+
+	// digestServer同时返回Basic及Digest质询, 按链中顺序优先选用Digest
+	chain := NewAuthChain(
+		&APIKeyAuth{Name: "X-Api-Key", Value: "k"},
+		NewDigestAuth("kevin", "secret"),
+		&BasicAuth{Username: "kevin", Password: "secret"},
+	)
+	config := NewConfig().SetAuthenticator(chain)
+	for i := 0; i < 2; i++ {
+		resp, err := NewRequestWithConfig(config, "GET", digestServer.URL).Do()
+		if err != nil || resp.StatusCode != http.StatusOK {
+			t.Fatalf("Digest认证失败: %v %v", resp, err)
+		}
+	}
+	if digestServer.challenges != 1 {
+		t.Errorf("期望1次Digest质询, 实际 %d", digestServer.challenges)
+	}
+
+	resp, err := NewRequestWithConfig(config, "GET", basicServer.URL).Do()
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("Basic认证失败: %v %v", resp, err)
+	}
+
+	// 服务器不支持链中的认证方式时不重发
+	chain = NewAuthChain(&BearerAuth{Token: "abc"})
+	resp, err = NewRequest("GET", basicServer.URL).SetAuthenticator(chain).Do()
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("期望401, 实际 %v %v", resp, err)
+	}
+}
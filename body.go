@@ -0,0 +1,141 @@
+package go_requests
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+)
+
+// 流式请求数据
+type streamBody struct {
+	body    io.ReadCloser
+	length  int64                         // 数据长度, 小于等于0时表示未知, 使用chunked编码发送
+	getBody func() (io.ReadCloser, error) // 重新获取请求数据, 用于重试及重定向, 为nil时不可重放
+}
+
+func (stream *streamBody) Read(p []byte) (int, error) {
+	return stream.body.Read(p)
+}
+
+func (stream *streamBody) Close() error {
+	return stream.body.Close()
+}
+
+// 设置请求的流式数据
+func (stream *streamBody) apply(r *http.Request) {
+	r.Body = stream.body
+	r.ContentLength = stream.length
+	r.GetBody = stream.getBody
+	if stream.length <= 0 {
+		r.ContentLength = -1
+	}
+}
+
+// 打开文件作为流式请求数据, 重放时重新打开文件
+func newFileBody(path string) (*streamBody, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &streamBody{
+		body:   file,
+		length: info.Size(),
+		getBody: func() (io.ReadCloser, error) {
+			return os.Open(path)
+		},
+	}, nil
+}
+
+// 使用io.Reader作为流式请求数据, 支持Seek时可重放并自动计算长度
+// 支持ReadAt时(如 bytes.Reader、strings.Reader、os.File)每次重放使用独立的 io.SectionReader, 签名及压缩时可同时读取;
+// 仅支持Seek时每次读取前回到起始位置, 各次读取需依次进行
+func newReaderBody(reader io.Reader, length int64) (*streamBody, error) {
+	stream := &streamBody{body: ioutil.NopCloser(reader), length: length} // 由调用方负责关闭reader
+	seeker, ok := reader.(io.Seeker)
+	if !ok {
+		return stream, nil
+	}
+	offset, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return stream, nil
+	}
+	if length <= 0 {
+		end, err := seeker.Seek(0, io.SeekEnd)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = seeker.Seek(offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+		stream.length = end - offset
+	}
+	if readerAt, ok := reader.(io.ReaderAt); ok {
+		size := stream.length
+		stream.getBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(io.NewSectionReader(readerAt, offset, size)), nil
+		}
+		stream.body, _ = stream.getBody()
+		return stream, nil
+	}
+	stream.getBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(&rewindReader{seeker: seeker, reader: reader, offset: offset}), nil
+	}
+	stream.body, _ = stream.getBody()
+	return stream, nil
+}
+
+// 首次读取时回到起始位置的Reader, 多次获取的请求数据依次读取时互不影响
+type rewindReader struct {
+	seeker  io.Seeker
+	reader  io.Reader
+	offset  int64
+	started bool
+}
+
+func (r *rewindReader) Read(p []byte) (int, error) {
+	if !r.started {
+		if _, err := r.seeker.Seek(r.offset, io.SeekStart); err != nil {
+			return 0, err
+		}
+		r.started = true
+	}
+	return r.reader.Read(p)
+}
+
+// 组装流式请求数据, 未设置Body及BodyFile时返回nil
+func (req *Request) getStream() (*streamBody, error) {
+	var stream *streamBody
+	var err error
+	switch {
+	case req.Body != nil:
+		stream, err = newReaderBody(req.Body, req.BodyLength)
+	case req.BodyFile != "":
+		stream, err = newFileBody(req.BodyFile)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !req.hasHeader("Content-Type") {
+		req.Headers["Content-Type"] = "application/octet-stream"
+	}
+	return stream, nil
+}
+
+// 是否已设置请求头, 不区分大小写
+func (req *Request) hasHeader(key string) bool {
+	key = http.CanonicalHeaderKey(key)
+	for name := range req.Headers {
+		if http.CanonicalHeaderKey(name) == key {
+			return true
+		}
+	}
+	return len(req.HeaderValues[key]) > 0
+}
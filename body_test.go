@@ -0,0 +1,84 @@
+package go_requests
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newEchoBodyServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		_, _ = fmt.Fprintf(w, "%d|%s|%s|%d", r.ContentLength, strings.Join(r.TransferEncoding, ","), r.Header.Get("Content-Type"), len(body))
+	}))
+}
+
+// 测试流式发送文件、二进制数据及未知长度的io.Reader
+func TestRequestBodyStream(t *testing.T) {
+	server := newEchoBodyServer()
+	defer server.Close()
+	logo, _ := ioutil.ReadFile("./testdata/logo.png")
+	size := len(logo)
+
+	cases := []struct {
+		name     string
+		req      *Request
+		expected string
+	}{
+		{"文件", NewRequest("PUT", server.URL).SetBodyFile("./testdata/logo.png", "image/png"),
+			fmt.Sprintf("%d||image/png|%d", size, size)},
+		{"二进制数据", NewRequest("PUT", server.URL).SetBodyBytes([]byte("hello"), ""),
+			"5||application/octet-stream|5"},
+		{"未知长度", NewRequest("PUT", server.URL).SetBodyReader(io.MultiReader(strings.NewReader("hello")), -1, "text/plain"),
+			"-1|chunked|text/plain|5"},
+		{"JSON请求", &Request{Url: server.URL, BodyFile: "./testdata/logo.png"},
+			fmt.Sprintf("%d||application/octet-stream|%d", size, size)},
+	}
+	for _, c := range cases {
+		resp, err := c.req.Do()
+		if err != nil {
+			t.Fatalf("%s: 请求失败: %s", c.name, err)
+		}
+		if resp.Text != c.expected {
+			t.Errorf("%s: 期望 %s, 实际 %s", c.name, c.expected, resp.Text)
+		}
+	}
+
+	if _, err := NewRequest("PUT", server.URL).SetBodyFile("./testdata/missing.bin", "").Do(); err == nil {
+		t.Fatal("期望文件不存在错误")
+	}
+}
+
+// 测试流式请求数据重试
+func TestRequestBodyStreamRetry(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		body, _ := ioutil.ReadAll(r.Body)
+		if string(body) != "hello" {
+			t.Errorf("第%d次请求数据不正确: %s", attempts, body)
+		}
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	policy := NewRetryPolicy(2).SetBackoff(1, 10)
+	resp, err := NewRequest("PUT", server.URL).SetBodyReader(bytes.NewReader([]byte("hello")), 0, "").SetRetry(policy).Do()
+	if err != nil || resp.StatusCode != 200 || resp.Attempts != 2 {
+		t.Fatalf("可Seek的请求数据应重试: %v", err)
+	}
+
+	// 不支持Seek的请求数据无法重放, 不重试
+	attempts = 0
+	resp, err = NewRequest("PUT", server.URL).SetBodyReader(io.MultiReader(strings.NewReader("hello")), 5, "").SetRetry(policy).Do()
+	if err != nil || resp.StatusCode != 503 || resp.Attempts != 1 {
+		t.Fatalf("不可重放的请求数据不应重试: %v", err)
+	}
+}
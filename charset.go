@@ -0,0 +1,130 @@
+package go_requests
+
+import (
+	"bytes"
+	"fmt"
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding"
+	"mime"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// 查找字符集, 支持gbk、gb2312、gb18030、big5、shift_jis等WHATWG标准名称及别名
+func lookupCharset(name string) (encoding.Encoding, string, error) {
+	enc, canonical := charset.Lookup(name)
+	if enc == nil {
+		return nil, "", fmt.Errorf("不支持的字符集 \"%s\"", name)
+	}
+	return enc, canonical, nil
+}
+
+// 是否为需要按HTML规则检测编码(含meta标签)的文本类型
+func isTextType(mediaType string) bool {
+	return strings.HasPrefix(mediaType, "text/") || mediaType == "application/xhtml+xml"
+}
+
+// 检测响应数据编码, 优先级: BOM > Content-Type中的charset > HTML meta标签 > 内容推测
+// 非文本类型(如JSON、图片)未声明charset时只检查BOM, 默认为utf-8
+func detectCharset(content []byte, contentType string) (encoding.Encoding, string) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		params = map[string]string{}
+	}
+	if contentType == "" || isTextType(mediaType) {
+		enc, name, certain := charset.DetermineEncoding(content, contentType)
+		if !certain && name == "windows-1252" && utf8.Valid(content) && !declaresWindows1252(content) {
+			return encoding.Nop, "utf-8"
+		}
+		return enc, name
+	}
+	switch {
+	case bytes.HasPrefix(content, []byte{0xEF, 0xBB, 0xBF}):
+		params["charset"] = "utf-8"
+	case bytes.HasPrefix(content, []byte{0xFE, 0xFF}):
+		params["charset"] = "utf-16be"
+	case bytes.HasPrefix(content, []byte{0xFF, 0xFE}):
+		params["charset"] = "utf-16le"
+	}
+	if enc, name, err := lookupCharset(params["charset"]); err == nil {
+		return enc, name
+	}
+	return encoding.Nop, "utf-8"
+}
+
+// 内容中的meta标签是否声明了windows-1252(或其别名), 用于区分DetermineEncoding的默认值
+// DetermineEncoding未找到非ASCII字符时默认返回windows-1252, 追加一个UTF-8字符后重新检测, 仍为windows-1252时说明来自meta标签
+func declaresWindows1252(content []byte) bool {
+	if len(content) > 1000 {
+		content = content[:1000]
+	}
+	probe := append(append([]byte{}, content...), "中 "...) // 末尾的多字节字符会被当作不完整字符忽略, 需后跟ASCII字符
+	_, name, _ := charset.DetermineEncoding(probe, "")
+	return name == "windows-1252"
+}
+
+// 设置响应数据, 按响应编码(或ResponseCharset)将响应文本转为UTF-8
+func (res *Response) setContent(content []byte) {
+	res.Content = content
+	res.Text = string(content)
+	var enc encoding.Encoding
+	if res.Request != nil && res.Request.ResponseCharset != "" {
+		enc, res.Charset, _ = lookupCharset(res.Request.ResponseCharset)
+	} else {
+		enc, res.Charset = detectCharset(content, res.Headers["Content-Type"])
+	}
+	if enc != nil && res.Charset != "utf-8" {
+		if text, err := enc.NewDecoder().Bytes(content); err == nil {
+			res.Text = string(text)
+		}
+	}
+	res.Text = strings.TrimPrefix(res.Text, "\uFEFF") // 去掉BOM
+}
+
+// 检查请求及响应字符集配置
+func (req *Request) checkCharset() error {
+	for _, name := range []string{req.RequestCharset, req.ResponseCharset} {
+		if name == "" {
+			continue
+		}
+		if _, _, err := lookupCharset(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 将请求文本编码为RequestCharset字符集
+func (req *Request) encodeText(text string) (string, error) {
+	if req.RequestCharset == "" {
+		return text, nil
+	}
+	enc, _, err := lookupCharset(req.RequestCharset)
+	if err != nil {
+		return "", err
+	}
+	return enc.NewEncoder().String(text)
+}
+
+// 将表单字段编码为RequestCharset字符集后进行URL编码
+func (req *Request) encodeForm(values url.Values) (string, error) {
+	if req.RequestCharset == "" {
+		return values.Encode(), nil
+	}
+	encoded := url.Values{}
+	for key, list := range values {
+		encodedKey, err := req.encodeText(key)
+		if err != nil {
+			return "", err
+		}
+		for _, value := range list {
+			encodedValue, err := req.encodeText(value)
+			if err != nil {
+				return "", err
+			}
+			encoded.Add(encodedKey, encodedValue)
+		}
+	}
+	return encoded.Encode(), nil
+}
@@ -0,0 +1,101 @@
+package go_requests
+
+import (
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// 测试按Content-Type、HTML meta标签、BOM及指定字符集转码响应文本
+func TestResponseCharset(t *testing.T) {
+	gbk, _ := simplifiedchinese.GBK.NewEncoder().String("你好，世界")
+	big5, _ := traditionalchinese.Big5.NewEncoder().String("你好，世界")
+	meta, _ := simplifiedchinese.GBK.NewEncoder().String(`<html><head><meta charset="gb2312"></head><body>你好，世界</body></html>`)
+	cases := []struct {
+		name        string
+		contentType string
+		body        string
+		override    string
+		charset     string
+		expected    string
+	}{
+		{"Content-Type", "text/html; charset=GBK", gbk, "", "gbk", "你好，世界"},
+		{"meta标签", "text/html", meta, "", "gbk", `<html><head><meta charset="gb2312"></head><body>你好，世界</body></html>`},
+		{"指定字符集", "text/plain", big5, "big5", "big5", "你好，世界"},
+		{"BOM", "application/json", "\xFF\xFEh\x00i\x00", "", "utf-16le", "hi"},
+		{"UTF-8", "application/json", `{"name":"你好"}`, "", "utf-8", `{"name":"你好"}`},
+		{"ASCII文本", "text/plain", "hello", "", "utf-8", "hello"},
+		{"ASCII HTML", "text/html", "<p>hello</p>", "", "utf-8", "<p>hello</p>"},
+	}
+	for _, c := range cases {
+		server := newContentServer(c.contentType, c.body)
+		resp, err := NewRequest("GET", server.URL).SetCharset("", c.override).Do()
+		server.Close()
+		if err != nil {
+			t.Fatalf("%s: 请求失败: %s", c.name, err)
+		}
+		if resp.Charset != c.charset || resp.Text != c.expected {
+			t.Errorf("%s: 期望 %s %s, 实际 %s %s", c.name, c.charset, c.expected, resp.Charset, resp.Text)
+		}
+		if string(resp.Content) != c.body {
+			t.Errorf("%s: 响应二进制内容不应转码", c.name)
+		}
+	}
+}
+
+// 测试未声明字符集时的编码推测, 合法UTF-8内容不应推测为windows-1252
+func TestDetectCharset(t *testing.T) {
+	cases := []struct {
+		contentType string
+		body        string
+		charset     string
+	}{
+		{"", "hello", "utf-8"},
+		{"text/plain", "hello", "utf-8"},
+		{"text/html", "<p>你好</p>", "utf-8"},
+		{"text/html", `<meta charset="iso-8859-1"><p>hello</p>`, "windows-1252"},
+		{"text/html", `<meta charset="utf-8"><p>hello</p>`, "utf-8"},
+		{"text/plain", "caf\xe9", "windows-1252"},
+	}
+	for _, c := range cases {
+		if _, name := detectCharset([]byte(c.body), c.contentType); name != c.charset {
+			t.Errorf("%q %q: 期望 %s, 实际 %s", c.contentType, c.body, c.charset, name)
+		}
+	}
+}
+
+// 测试以指定字符集发送表单及Raw请求数据
+func TestRequestCharset(t *testing.T) {
+	var contentType string
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		body, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	config := NewConfig().SetCharset("gbk", "")
+	if _, err := NewRequestWithConfig(config, "POST", server.URL).SetFormData(map[string]string{"name": "张三"}).Do(); err != nil {
+		t.Fatalf("请求失败: %s", err)
+	}
+	values, _ := url.ParseQuery(string(body))
+	name, _ := simplifiedchinese.GBK.NewDecoder().String(values.Get("name"))
+	if name != "张三" || contentType != "application/x-www-form-urlencoded; charset=gbk" {
+		t.Errorf("GBK表单错误: %s %s", name, contentType)
+	}
+
+	if _, err := NewRequest("POST", server.URL).SetRawData("张三").SetCharset("gb18030", "").Do(); err != nil {
+		t.Fatalf("请求失败: %s", err)
+	}
+	if raw, _ := simplifiedchinese.GB18030.NewDecoder().Bytes(body); string(raw) != "张三" {
+		t.Errorf("GB18030 Raw数据错误: %x", body)
+	}
+
+	if _, err := NewRequest("POST", server.URL).SetRawData("a").SetCharset("unknown", "").Do(); err == nil {
+		t.Error("期望不支持的字符集错误")
+	}
+}
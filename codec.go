@@ -0,0 +1,334 @@
+package go_requests
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"golang.org/x/net/html/charset"
+	"gopkg.in/yaml.v3"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Encoder 将Go值编码为请求数据
+type Encoder interface {
+	Encode(v interface{}) ([]byte, error)
+}
+
+// EncoderFunc 函数形式的Encoder
+type EncoderFunc func(v interface{}) ([]byte, error)
+
+func (f EncoderFunc) Encode(v interface{}) ([]byte, error) {
+	return f(v)
+}
+
+// Decoder 将响应数据解码为Go值, useNumber为true时数字应解码为json.Number等不损失精度的类型
+type Decoder interface {
+	Decode(data []byte, v interface{}, useNumber bool) error
+}
+
+// DecoderFunc 函数形式的Decoder
+type DecoderFunc func(data []byte, v interface{}, useNumber bool) error
+
+func (f DecoderFunc) Decode(data []byte, v interface{}, useNumber bool) error {
+	return f(data, v, useNumber)
+}
+
+// Codec 同时支持编码及解码的格式, 如MessagePack、Protobuf
+type Codec interface {
+	Encoder
+	Decoder
+}
+
+var (
+	codecMu  sync.RWMutex
+	encoders = map[string]Encoder{
+		"application/json":                  EncoderFunc(json.Marshal),
+		"application/xml":                   EncoderFunc(xml.Marshal),
+		"text/xml":                          EncoderFunc(xml.Marshal),
+		"application/yaml":                  EncoderFunc(yaml.Marshal),
+		"application/x-yaml":                EncoderFunc(yaml.Marshal),
+		"text/yaml":                         EncoderFunc(yaml.Marshal),
+		"application/x-www-form-urlencoded": EncoderFunc(encodeForm),
+	}
+	decoders = map[string]Decoder{
+		"application/json":                  DecoderFunc(decodeJson),
+		"application/xml":                   DecoderFunc(decodeXml),
+		"text/xml":                          DecoderFunc(decodeXml),
+		"application/yaml":                  DecoderFunc(decodeYaml),
+		"application/x-yaml":                DecoderFunc(decodeYaml),
+		"text/yaml":                         DecoderFunc(decodeYaml),
+		"application/x-www-form-urlencoded": DecoderFunc(decodeForm),
+	}
+)
+
+// RegisterEncoder 注册指定媒体类型的编码器, 已存在时覆盖
+func RegisterEncoder(mediaType string, encoder Encoder) {
+	codecMu.Lock()
+	defer codecMu.Unlock()
+	encoders[strings.ToLower(mediaType)] = encoder
+}
+
+// RegisterDecoder 注册指定媒体类型(如 application/msgpack)的解码器, 已存在时覆盖
+// 注册 application/xxx 后, 带 +xxx 后缀的媒体类型也使用该解码器
+func RegisterDecoder(mediaType string, decoder Decoder) {
+	codecMu.Lock()
+	defer codecMu.Unlock()
+	decoders[strings.ToLower(mediaType)] = decoder
+}
+
+// RegisterCodec 注册指定媒体类型的编码器及解码器
+func RegisterCodec(mediaType string, codec Codec) {
+	RegisterEncoder(mediaType, codec)
+	RegisterDecoder(mediaType, codec)
+}
+
+// 按Content-Type查找已注册的媒体类型, 支持 application/problem+json 等结构化后缀
+func lookupMediaType(contentType string, registered func(mediaType string) bool) (string, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", err
+	}
+	if registered(mediaType) {
+		return mediaType, nil
+	}
+	if i := strings.LastIndex(mediaType, "+"); i >= 0 && registered("application/"+mediaType[i+1:]) {
+		return "application/" + mediaType[i+1:], nil
+	}
+	return "", fmt.Errorf("不支持的Content-Type \"%s\"", mediaType)
+}
+
+// 按Content-Type查找编码器
+func findEncoder(contentType string) (Encoder, error) {
+	codecMu.RLock()
+	defer codecMu.RUnlock()
+	mediaType, err := lookupMediaType(contentType, func(mediaType string) bool {
+		_, ok := encoders[mediaType]
+		return ok
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w, 可通过RegisterEncoder注册编码器", err)
+	}
+	return encoders[mediaType], nil
+}
+
+// 按Content-Type查找解码器, 未设置Content-Type时按JSON解码
+func findDecoder(contentType string) (Decoder, error) {
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	codecMu.RLock()
+	defer codecMu.RUnlock()
+	mediaType, err := lookupMediaType(contentType, func(mediaType string) bool {
+		_, ok := decoders[mediaType]
+		return ok
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w, 可通过RegisterDecoder注册解码器", err)
+	}
+	return decoders[mediaType], nil
+}
+
+// 按BodyType使用已注册的编码器编码请求数据
+func (req *Request) encodeBody() (io.Reader, error) {
+	contentType := req.BodyType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	encoder, err := findEncoder(contentType)
+	if err != nil {
+		return nil, err
+	}
+	data, err := encoder.Encode(req.BodyValue)
+	if err != nil {
+		return nil, fmt.Errorf("编码请求数据出错: %w", err)
+	}
+	if !req.hasHeader("Content-Type") {
+		req.Headers["Content-Type"] = contentType
+	}
+	return bytes.NewReader(data), nil
+}
+
+// DecodeError 响应解码错误, 包含部分响应内容便于排查
+type DecodeError struct {
+	StatusCode  int    // 响应状态码
+	ContentType string // 响应Content-Type
+	Snippet     string // 响应内容片段, 最多200字节
+	Err         error  // 原始错误
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("解码响应出错(状态码 %d, Content-Type \"%s\"): %s, 响应内容: %s", e.StatusCode, e.ContentType, e.Err, e.Snippet)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+const snippetSize = 200
+
+// 截取响应内容片段, 不截断多字节字符
+func snippet(data []byte) string {
+	if len(data) <= snippetSize {
+		return string(data)
+	}
+	text := string(data[:snippetSize])
+	for len(text) > 0 && !utf8.ValidString(text) {
+		text = text[:len(text)-1]
+	}
+	return text + "..."
+}
+
+// Decode 按响应Content-Type将响应数据解码到v, 支持JSON、XML、表单及通过RegisterDecoder注册的格式
+// 请求设置UseNumber时JSON数字解码为json.Number, 流式响应会读取并关闭Body
+func (res *Response) Decode(v interface{}) error {
+	contentType := res.Headers["Content-Type"]
+	if res.Body != nil && res.Content == nil {
+		data, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			return &DecodeError{StatusCode: res.StatusCode, ContentType: contentType, Err: err}
+		}
+		res.setContent(data)
+	}
+	decoder, err := findDecoder(contentType)
+	if err == nil {
+		useNumber := res.Request != nil && res.Request.UseNumber
+		err = decoder.Decode(res.Content, v, useNumber)
+	}
+	if err != nil {
+		return &DecodeError{StatusCode: res.StatusCode, ContentType: contentType, Snippet: snippet(res.Content), Err: err}
+	}
+	return nil
+}
+
+func decodeJson(data []byte, v interface{}, useNumber bool) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if useNumber {
+		decoder.UseNumber()
+	}
+	return decoder.Decode(v)
+}
+
+// 解码XML, 支持 encoding="gbk" 等非UTF-8声明
+func decodeXml(data []byte, v interface{}, useNumber bool) error {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.CharsetReader = charset.NewReaderLabel
+	return decoder.Decode(v)
+}
+
+func decodeYaml(data []byte, v interface{}, useNumber bool) error {
+	return yaml.Unmarshal(data, v)
+}
+
+// 编码表单数据, v可以是 url.Values、map[string][]string 或 map[string]string
+func encodeForm(v interface{}) ([]byte, error) {
+	values := url.Values{}
+	switch data := v.(type) {
+	case url.Values:
+		values = data
+	case map[string][]string:
+		values = data
+	case map[string]string:
+		for key, value := range data {
+			values.Set(key, value)
+		}
+	default:
+		return nil, fmt.Errorf("%T 不能编码为表单数据", v)
+	}
+	return []byte(values.Encode()), nil
+}
+
+// 解码表单数据, v可以是 *url.Values、*map[string][]string、*map[string]string 或结构体指针(按form标签或字段名匹配)
+func decodeForm(data []byte, v interface{}, useNumber bool) error {
+	values, err := url.ParseQuery(string(data))
+	if err != nil {
+		return err
+	}
+	switch target := v.(type) {
+	case *url.Values:
+		*target = values
+		return nil
+	case *map[string][]string:
+		*target = values
+		return nil
+	case *map[string]string:
+		*target = map[string]string{}
+		for key := range values {
+			(*target)[key] = values.Get(key)
+		}
+		return nil
+	}
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("表单数据不能解码到 %T", v)
+	}
+	value = value.Elem()
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		key := field.Tag.Get("form")
+		if key == "-" || field.PkgPath != "" {
+			continue
+		}
+		if key == "" {
+			key = field.Name
+		}
+		list, ok := values[key]
+		if !ok || len(list) == 0 {
+			continue
+		}
+		if err := setFormField(value.Field(i), list); err != nil {
+			return fmt.Errorf("表单字段\"%s\": %w", key, err)
+		}
+	}
+	return nil
+}
+
+// 设置结构体字段值, 支持字符串、数字、布尔值及字符串切片
+func setFormField(field reflect.Value, list []string) error {
+	text := list[0]
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(text)
+	case reflect.Bool:
+		value, err := strconv.ParseBool(text)
+		if err != nil {
+			return err
+		}
+		field.SetBool(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value, err := strconv.ParseInt(text, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(value)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		value, err := strconv.ParseUint(text, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(value)
+	case reflect.Float32, reflect.Float64:
+		value, err := strconv.ParseFloat(text, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(value)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("不支持的类型 %s", field.Type())
+		}
+		field.Set(reflect.ValueOf(append([]string(nil), list...)).Convert(field.Type()))
+	default:
+		return fmt.Errorf("不支持的类型 %s", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package go_requests
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func newContentServer(contentType, body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if contentType != "" {
+			w.Header().Set("Content-Type", contentType)
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+}
+
+type user struct {
+	Id   int64    `json:"id" xml:"id" form:"id"`
+	Name string   `json:"name" xml:"name" form:"name"`
+	Tags []string `json:"tags" xml:"tag" form:"tag"`
+}
+
+// 测试按Content-Type解码JSON、XML及表单
+func TestResponseDecode(t *testing.T) {
+	cases := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{"JSON", "application/json; charset=utf-8", `{"id":1,"name":"kevin","tags":["a","b"]}`},
+		{"JSON后缀", "application/vnd.api+json", `{"id":1,"name":"kevin","tags":["a","b"]}`},
+		{"XML", "application/xml", `<user><id>1</id><name>kevin</name><tag>a</tag><tag>b</tag></user>`},
+		{"XML后缀", "application/atom+xml", `<user><id>1</id><name>kevin</name><tag>a</tag><tag>b</tag></user>`},
+		{"表单", "application/x-www-form-urlencoded", `id=1&name=kevin&tag=a&tag=b`},
+	}
+	for _, c := range cases {
+		server := newContentServer(c.contentType, c.body)
+		resp, err := NewRequest("GET", server.URL).Do()
+		server.Close()
+		if err != nil {
+			t.Fatalf("%s: 请求失败: %s", c.name, err)
+		}
+		var u user
+		if err := resp.Decode(&u); err != nil {
+			t.Fatalf("%s: 解码失败: %s", c.name, err)
+		}
+		if u.Id != 1 || u.Name != "kevin" || strings.Join(u.Tags, ",") != "a,b" {
+			t.Errorf("%s: 解码结果错误 %+v", c.name, u)
+		}
+	}
+}
+
+// 测试UseNumber避免大整数丢失精度
+func TestResponseDecodeUseNumber(t *testing.T) {
+	server := newContentServer("application/json", `{"id":9007199254740993}`)
+	defer server.Close()
+
+	config := NewConfig().SetUseNumber(true)
+	resp, err := NewRequestWithConfig(config, "GET", server.URL).Do()
+	if err != nil {
+		t.Fatalf("请求失败: %s", err)
+	}
+	var data map[string]interface{}
+	if err := resp.Decode(&data); err != nil {
+		t.Fatalf("解码失败: %s", err)
+	}
+	if id, ok := data["id"].(json.Number); !ok || id.String() != "9007199254740993" {
+		t.Errorf("期望json.Number 9007199254740993, 实际 %#v", data["id"])
+	}
+}
+
+// 测试解码错误包含响应内容片段
+func TestResponseDecodeError(t *testing.T) {
+	body := "<html>" + strings.Repeat("错误", 100) + "</html>"
+	server := newContentServer("application/json", body)
+	defer server.Close()
+
+	resp, _ := NewRequest("GET", server.URL).Do()
+	var data map[string]interface{}
+	err := resp.Decode(&data)
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("期望DecodeError, 实际 %v", err)
+	}
+	if !strings.HasPrefix(decodeErr.Snippet, "<html>错误") || !strings.HasSuffix(decodeErr.Snippet, "...") || len(decodeErr.Snippet) > snippetSize+3 {
+		t.Errorf("响应内容片段错误: %s", decodeErr.Snippet)
+	}
+
+	server2 := newContentServer("application/msgpack", "\x81")
+	defer server2.Close()
+	resp, _ = NewRequest("GET", server2.URL).Do()
+	if err := resp.Decode(&data); err == nil || !strings.Contains(err.Error(), "RegisterDecoder") {
+		t.Errorf("期望不支持的Content-Type错误, 实际 %v", err)
+	}
+}
+
+// 测试注册自定义解码器及解码流式响应
+func TestRegisterDecoder(t *testing.T) {
+	RegisterDecoder("text/csv", DecoderFunc(func(data []byte, v interface{}, useNumber bool) error {
+		*(v.(*[][]string)) = [][]string{strings.Split(strings.TrimSpace(string(data)), ",")}
+		return nil
+	}))
+	server := newContentServer("text/csv", "a,b,c\n")
+	defer server.Close()
+
+	resp, err := NewRequest("GET", server.URL).SetStream(true).Do()
+	if err != nil {
+		t.Fatalf("请求失败: %s", err)
+	}
+	var rows [][]string
+	if err := resp.Decode(&rows); err != nil {
+		t.Fatalf("解码失败: %s", err)
+	}
+	if len(rows) != 1 || strings.Join(rows[0], "|") != "a|b|c" {
+		t.Errorf("解码结果错误 %v", rows)
+	}
+
+	var values url.Values
+	form := &Response{Headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded"}, Content: []byte("a=1&a=2")}
+	if err := form.Decode(&values); err != nil || len(values["a"]) != 2 {
+		t.Errorf("表单解码错误: %v %v", values, err)
+	}
+}
+
+// 原样返回请求数据及Content-Type的服务
+func newEchoServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+		_, _ = io.Copy(w, r.Body)
+	}))
+}
+
+// 测试按Content-Type编码请求数据并解码响应
+func TestRequestSetBody(t *testing.T) {
+	server := newEchoServer()
+	defer server.Close()
+
+	for _, contentType := range []string{"", "application/xml", "application/yaml", "application/vnd.api+json"} {
+		input := user{Id: 1, Name: "kevin", Tags: []string{"a", "b"}}
+		resp, err := NewRequest("POST", server.URL).SetBody(input, contentType).Do()
+		if err != nil {
+			t.Fatalf("%s: 请求失败: %s", contentType, err)
+		}
+		var output user
+		if err := resp.Decode(&output); err != nil {
+			t.Fatalf("%s: 解码失败: %s", contentType, err)
+		}
+		if output.Id != 1 || output.Name != "kevin" || len(output.Tags) != 2 {
+			t.Errorf("%s: 解码结果错误 %+v", contentType, output)
+		}
+	}
+
+	resp, err := NewRequest("POST", server.URL).SetBody(map[string]string{"a": "1"}, "application/x-www-form-urlencoded").Do()
+	if err != nil || resp.Text != "a=1" {
+		t.Errorf("表单编码错误: %s %v", resp.Text, err)
+	}
+
+	if _, err := NewRequest("POST", server.URL).SetBody(map[string]int{"a": 1}, "application/unknown").Do(); !errors.Is(err, ErrBuildBody) {
+		t.Errorf("期望ErrBuildBody, 实际 %v", err)
+	}
+}
+
+// 大写文本编解码器
+type upperCodec struct{}
+
+func (upperCodec) Encode(v interface{}) ([]byte, error) {
+	return []byte(strings.ToUpper(v.(string))), nil
+}
+
+func (upperCodec) Decode(data []byte, v interface{}, useNumber bool) error {
+	*(v.(*string)) = strings.ToLower(string(data))
+	return nil
+}
+
+// 测试注册自定义编解码器
+func TestRegisterCodec(t *testing.T) {
+	RegisterCodec("application/x-upper", upperCodec{})
+	server := newEchoServer()
+	defer server.Close()
+
+	resp, err := NewRequest("POST", server.URL).SetBody("hello", "application/x-upper").Do()
+	if err != nil {
+		t.Fatalf("请求失败: %s", err)
+	}
+	var text string
+	if resp.Text != "HELLO" || resp.Decode(&text) != nil || text != "hello" {
+		t.Errorf("编解码错误: %s %s", resp.Text, text)
+	}
+}
@@ -0,0 +1,174 @@
+package go_requests
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+const acceptEncoding = "gzip, deflate, br, zstd" // 默认Accept-Encoding
+
+// 响应数据解压方法
+var decompressors = map[string]func(reader io.Reader) (io.ReadCloser, error){
+	"gzip": func(reader io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(reader)
+	},
+	"x-gzip": func(reader io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(reader)
+	},
+	"deflate": newDeflateReader,
+	"br": func(reader io.Reader) (io.ReadCloser, error) {
+		return ioutil.NopCloser(brotli.NewReader(reader)), nil
+	},
+	"zstd": func(reader io.Reader) (io.ReadCloser, error) {
+		decoder, err := zstd.NewReader(reader)
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
+	},
+}
+
+// 请求数据压缩方法
+var compressors = map[string]func(writer io.Writer) (io.WriteCloser, error){
+	"gzip": func(writer io.Writer) (io.WriteCloser, error) {
+		return gzip.NewWriter(writer), nil
+	},
+	"zstd": func(writer io.Writer) (io.WriteCloser, error) {
+		return zstd.NewWriter(writer)
+	},
+}
+
+// deflate按规范为zlib格式, 部分服务器返回不带zlib头的原始deflate数据
+func newDeflateReader(reader io.Reader) (io.ReadCloser, error) {
+	buffered := bufio.NewReader(reader)
+	header, err := buffered.Peek(2)
+	if err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(buffered)
+	}
+	return flate.NewReader(buffered), nil
+}
+
+// 解压后的响应数据, 关闭时同时关闭解压器及原始响应数据
+type decodedBody struct {
+	reader  io.Reader
+	closers []io.Closer
+}
+
+func (body *decodedBody) Read(p []byte) (int, error) {
+	return body.reader.Read(p)
+}
+
+func (body *decodedBody) Close() error {
+	var err error
+	for i := len(body.closers) - 1; i >= 0; i-- {
+		if closeErr := body.closers[i].Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// 设置默认Accept-Encoding, 用户已设置、Range请求及HEAD请求除外
+func setAcceptEncoding(r *http.Request) {
+	if r.Header.Get("Accept-Encoding") != "" || r.Header.Get("Range") != "" || r.Method == http.MethodHead {
+		return
+	}
+	r.Header.Set("Accept-Encoding", acceptEncoding)
+}
+
+// 按Content-Encoding解压响应数据, 不支持的编码保持原样
+// 设置KeepRawContent时先读取全部原始数据并返回
+func (req *Request) decompress(res *http.Response) ([]byte, error) {
+	if res.Uncompressed {
+		return nil, nil // 已由Transport解压
+	}
+	var encodings []string
+	for _, item := range strings.Split(res.Header.Get("Content-Encoding"), ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item == "" || item == "identity" {
+			continue
+		}
+		if _, ok := decompressors[item]; !ok {
+			return nil, nil
+		}
+		encodings = append(encodings, item)
+	}
+	if len(encodings) == 0 {
+		return nil, nil
+	}
+	var raw []byte
+	var reader io.Reader = res.Body
+	if req.KeepRawContent && !req.Stream {
+		data, err := ioutil.ReadAll(res.Body)
+		if err != nil {
+			return nil, err
+		}
+		raw = data
+		reader = bytes.NewReader(raw)
+	}
+	body := &decodedBody{closers: []io.Closer{res.Body}}
+	// 多个编码按应用顺序的逆序解压
+	for i := len(encodings) - 1; i >= 0; i-- {
+		decoded, err := decompressors[encodings[i]](reader)
+		if err != nil {
+			return raw, fmt.Errorf("解压响应数据(%s)出错: %w", encodings[i], err)
+		}
+		reader = decoded
+		body.closers = append(body.closers, decoded)
+	}
+	body.reader = reader
+	res.Body = body
+	res.ContentLength = -1
+	return raw, nil
+}
+
+// 按Compress压缩请求数据并设置Content-Encoding, 使用管道边压缩边发送
+func (req *Request) compressBody(r *http.Request) error {
+	if req.Compress == "" || r.Body == nil || r.Body == http.NoBody || r.Header.Get("Content-Encoding") != "" {
+		return nil
+	}
+	newWriter, ok := compressors[strings.ToLower(req.Compress)]
+	if !ok {
+		return fmt.Errorf("不支持的请求数据压缩方式 \"%s\", 仅支持gzip及zstd", req.Compress)
+	}
+	r.Body = compressReader(r.Body, newWriter)
+	r.ContentLength = -1
+	if getBody := r.GetBody; getBody != nil {
+		r.GetBody = func() (io.ReadCloser, error) {
+			body, err := getBody()
+			if err != nil {
+				return nil, err
+			}
+			return compressReader(body, newWriter), nil
+		}
+	}
+	r.Header.Set("Content-Encoding", strings.ToLower(req.Compress))
+	return nil
+}
+
+// 压缩数据流
+func compressReader(body io.ReadCloser, newWriter func(writer io.Writer) (io.WriteCloser, error)) io.ReadCloser {
+	reader, pipeWriter := io.Pipe()
+	go func() {
+		defer body.Close()
+		writer, err := newWriter(pipeWriter)
+		if err == nil {
+			_, err = io.Copy(writer, body)
+			if closeErr := writer.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		pipeWriter.CloseWithError(err)
+	}()
+	return reader
+}
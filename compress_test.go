@@ -0,0 +1,140 @@
+package go_requests
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// 按encoding参数压缩响应数据的服务, 响应头X-Accept-Encoding返回收到的Accept-Encoding
+func newCompressServer(content string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding := r.URL.Query().Get("encoding")
+		w.Header().Set("X-Accept-Encoding", r.Header.Get("Accept-Encoding"))
+		buffer := &bytes.Buffer{}
+		var writer io.WriteCloser
+		switch encoding {
+		case "gzip":
+			writer = gzip.NewWriter(buffer)
+		case "deflate":
+			writer = zlib.NewWriter(buffer)
+		case "raw-deflate":
+			writer, _ = flate.NewWriter(buffer, flate.DefaultCompression)
+			encoding = "deflate"
+		case "br":
+			writer = brotli.NewWriter(buffer)
+		case "zstd":
+			writer, _ = zstd.NewWriter(buffer)
+		}
+		if writer != nil {
+			_, _ = writer.Write([]byte(content))
+			_ = writer.Close()
+			w.Header().Set("Content-Encoding", encoding)
+		} else {
+			buffer.WriteString(content)
+		}
+		_, _ = w.Write(buffer.Bytes())
+	}))
+}
+
+// 测试解压gzip、deflate、br及zstd响应
+func TestResponseDecompress(t *testing.T) {
+	server := newCompressServer("hello world")
+	defer server.Close()
+
+	for _, encoding := range []string{"", "gzip", "deflate", "raw-deflate", "br", "zstd"} {
+		resp, err := NewRequest("GET", server.URL).SetParams(map[string]string{"encoding": encoding}).Do()
+		if err != nil {
+			t.Fatalf("%s: 请求失败: %s", encoding, err)
+		}
+		if resp.Text != "hello world" {
+			t.Errorf("%s: 期望 hello world, 实际 %q", encoding, resp.Text)
+		}
+		if resp.Headers["X-Accept-Encoding"] != acceptEncoding {
+			t.Errorf("%s: Accept-Encoding错误 %s", encoding, resp.Headers["X-Accept-Encoding"])
+		}
+	}
+}
+
+// 测试自定义Accept-Encoding、保留原始数据及流式响应解压
+func TestResponseDecompressOptions(t *testing.T) {
+	server := newCompressServer("hello world")
+	defer server.Close()
+
+	resp, err := NewRequest("GET", server.URL+"?encoding=gzip").
+		SetHeaders(map[string]string{"Accept-Encoding": "gzip"}).
+		SetKeepRawContent(true).Do()
+	if err != nil {
+		t.Fatalf("请求失败: %s", err)
+	}
+	if resp.Text != "hello world" || resp.Headers["X-Accept-Encoding"] != "gzip" {
+		t.Errorf("自定义Accept-Encoding时仍应解压: %q", resp.Text)
+	}
+	if reader, err := gzip.NewReader(bytes.NewReader(resp.RawContent)); err != nil {
+		t.Error("RawContent应为gzip原始数据")
+	} else if data, _ := ioutil.ReadAll(reader); string(data) != "hello world" {
+		t.Errorf("RawContent解压结果错误: %s", data)
+	}
+
+	resp, err = NewRequest("GET", server.URL+"?encoding=br").SetStream(true).Do()
+	if err != nil {
+		t.Fatalf("请求失败: %s", err)
+	}
+	defer resp.Close()
+	if data, _ := ioutil.ReadAll(resp.Body); string(data) != "hello world" || resp.ContentLength != -1 {
+		t.Errorf("流式响应解压错误: %s %d", data, resp.ContentLength)
+	}
+
+	resp, _ = NewRequest("GET", server.URL).SetHeaders(map[string]string{"Range": "bytes=0-1"}).Do()
+	if resp.Headers["X-Accept-Encoding"] != "" {
+		t.Errorf("Range请求不应设置Accept-Encoding: %s", resp.Headers["X-Accept-Encoding"])
+	}
+}
+
+// 测试压缩请求数据及重试时重新压缩
+func TestRequestCompress(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		var reader io.Reader
+		switch r.Header.Get("Content-Encoding") {
+		case "gzip":
+			reader, _ = gzip.NewReader(r.Body)
+		case "zstd":
+			reader, _ = zstd.NewReader(r.Body)
+		default:
+			reader = r.Body
+		}
+		data, _ := ioutil.ReadAll(reader)
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_, _ = w.Write(data)
+	}))
+	defer server.Close()
+
+	for _, compress := range []string{"gzip", "zstd"} {
+		attempts = 0
+		resp, err := NewRequestWithConfig(NewConfig().SetCompress(compress), "PUT", server.URL).
+			SetJsonData(`{"name":"kevin"}`).
+			SetRetry(NewRetryPolicy(2).SetBackoff(1, 1)).Do()
+		if err != nil {
+			t.Fatalf("%s: 请求失败: %s", compress, err)
+		}
+		if resp.StatusCode != 200 || resp.Text != `{"name":"kevin"}` || attempts != 2 {
+			t.Errorf("%s: 压缩请求数据错误: %d %s %d", compress, resp.StatusCode, resp.Text, attempts)
+		}
+	}
+
+	if _, err := NewRequest("POST", server.URL).SetRawData("a").SetCompress("lzma").Do(); err == nil {
+		t.Error("期望不支持的压缩方式错误")
+	}
+}
@@ -1,16 +1,58 @@
 package go_requests
 
+import (
+	"net/http"
+	"net/url"
+)
+
 // Config 请求配置
 type Config struct {
-	BaseUrl string            `json:"base_url"` // 基础url
-	Params  map[string]string `json:"params"`   // 默认Query参数
-	Headers map[string]string `json:"headers"`  // 默认请求头
-	Cookies map[string]string `json:"cookies"`  // 默认请求头
-	Auth    []string          `json:"auth"`     // 默认BasicAuth授权用户名及密码
-	Timeout int               `json:"timeout"`  // 默认超时时间，单位 毫秒
-	HTTP2   bool              `json:"http_2"`   // 是否默认启用HTTP2，默认不启用
-	Proxy   string            `json:"proxy"`    // 默认代理地址 例如  "http://127.0.0.1:8888"
-	// todo 暴露跟多 http.Transport 所需配置/**/
+	BaseUrl      string            `json:"base_url"`      // 基础url
+	Params       map[string]string `json:"params"`        // 默认Query参数
+	Headers      map[string]string `json:"headers"`       // 默认请求头
+	Cookies      map[string]string `json:"cookies"`       // 默认请求头
+	ParamValues  url.Values        `json:"param_values"`  // 默认多值Query参数
+	HeaderValues http.Header       `json:"header_values"` // 默认多值请求头
+	Auth         []string          `json:"auth"`          // 默认BasicAuth授权用户名及密码
+	DigestAuth   *DigestAuth       `json:"digest_auth"`   // 默认Digest摘要认证，使用该配置的请求共享nonce
+	OAuth2       *OAuth2           `json:"oauth2"`        // 默认OAuth2认证，使用该配置的请求共享令牌
+	Signer       Signer            `json:"-"`             // 默认请求签名
+	Timeout      int               `json:"timeout"`       // 默认超时时间，单位 毫秒
+	HTTP2        bool              `json:"http_2"`        // 是否默认启用HTTP2，默认不启用
+	Proxy        string            `json:"proxy"`         // 默认代理地址 例如  "http://127.0.0.1:8888"
+	ProxyConfig  *ProxyConfig      `json:"proxy_config"`  // 默认代理配置
+	Retry        *RetryPolicy      `json:"retry"`         // 默认重试策略
+	TLS          *TLSConfig        `json:"tls"`           // 默认TLS配置，客户端证书及CA证书等
+	UseNumber    bool              `json:"use_number"`    // Response.Decode解码JSON时数字默认使用json.Number
+	JsonOptions  *JsonOptions      `json:"json_options"`  // 默认JSON序列化配置
+	Compress     string            `json:"compress"`      // 默认请求数据压缩方式，支持 "gzip" 及 "zstd"
+
+	KeepRawContent bool `json:"keep_raw_content"` // 默认保留压缩的原始响应数据
+
+	RequestCharset  string `json:"request_charset"`  // 默认表单及Raw请求数据字符集
+	ResponseCharset string `json:"response_charset"` // 默认响应文本字符集，默认自动检测
+
+	Netrc            bool   `json:"netrc"` // 未设置Auth时默认从netrc文件查找用户名及密码
+	CredentialHelper string `json:"-"`     // 默认凭证助手命令，只能在代码中设置，不从配置文件读取
+
+	UploadProgress   ProgressFunc `json:"-"`              // 默认上传进度回调
+	DownloadProgress ProgressFunc `json:"-"`              // 默认下载进度回调
+	UploadLimit      int64        `json:"upload_limit"`   // 默认上传限速，单位 字节/秒，默认不限速
+	DownloadLimit    int64        `json:"download_limit"` // 默认下载限速，单位 字节/秒，默认不限速
+
+	Authenticator Authenticator `json:"-"` // 默认认证方式
+
+	UnixSocket  string          `json:"unix_socket"` // 默认连接的Unix socket，如 "unix:///var/run/docker.sock"
+	DialContext DialContextFunc `json:"-"`           // 默认自定义拨号函数，使用该配置的请求复用连接
+	LocalAddr   string          `json:"local_addr"`  // 默认连接使用的本地IP地址
+	PreferIP    string          `json:"prefer_ip"`   // 默认优先连接的IP版本，"ipv4" 或 "ipv6"
+
+	MaxIdleConns        int `json:"max_idle_conns"`          // 连接池最大空闲连接数，默认100
+	MaxIdleConnsPerHost int `json:"max_idle_conns_per_host"` // 每个主机最大空闲连接数，默认2
+	MaxConnsPerHost     int `json:"max_conns_per_host"`      // 每个主机最大连接数，默认不限制
+	IdleConnTimeout     int `json:"idle_conn_timeout"`       // 空闲连接超时时间，单位 毫秒，默认90秒
+
+	transports *transportPool // 连接池，按TLS、代理及HTTP2配置复用 http.Transport
 }
 
 var GlobalConfig = &Config{} // 全局配置
@@ -34,6 +76,24 @@ func (conf *Config) SetHeaders(headers map[string]string) *Config {
 	return conf
 }
 
+// AddParam 添加默认Query参数，同名参数保留多个值
+func (conf *Config) AddParam(key, value string) *Config {
+	if conf.ParamValues == nil {
+		conf.ParamValues = url.Values{}
+	}
+	conf.ParamValues.Add(key, value)
+	return conf
+}
+
+// AddHeader 添加默认请求头，同名请求头保留多个值
+func (conf *Config) AddHeader(key, value string) *Config {
+	if conf.HeaderValues == nil {
+		conf.HeaderValues = http.Header{}
+	}
+	conf.HeaderValues.Add(key, value)
+	return conf
+}
+
 func (conf *Config) SetCookies(cookies map[string]string) *Config {
 	conf.Cookies = cookies
 	return conf
@@ -44,6 +104,37 @@ func (conf *Config) SetBasicAuth(auth []string) *Config {
 	return conf
 }
 
+// SetDigestAuth 设置默认Digest摘要认证用户名及密码
+func (conf *Config) SetDigestAuth(username, password string) *Config {
+	conf.DigestAuth = NewDigestAuth(username, password)
+	return conf
+}
+
+func (conf *Config) SetOAuth2(oauth2 *OAuth2) *Config {
+	conf.OAuth2 = oauth2
+	return conf
+}
+
+func (conf *Config) SetAuthenticator(auth Authenticator) *Config {
+	conf.Authenticator = auth
+	return conf
+}
+
+func (conf *Config) SetNetrc(enable bool) *Config {
+	conf.Netrc = enable
+	return conf
+}
+
+func (conf *Config) SetCredentialHelper(command string) *Config {
+	conf.CredentialHelper = command
+	return conf
+}
+
+func (conf *Config) SetSigner(signer Signer) *Config {
+	conf.Signer = signer
+	return conf
+}
+
 func (conf *Config) SetTimeout(timeout int) *Config {
 	conf.Timeout = timeout
 	return conf
@@ -54,7 +145,102 @@ func (conf *Config) SetProxy(proxy string) *Config {
 	return conf
 }
 
+func (conf *Config) SetProxyConfig(proxyConfig *ProxyConfig) *Config {
+	conf.ProxyConfig = proxyConfig
+	return conf
+}
+
+func (conf *Config) SetUnixSocket(socket string) *Config {
+	conf.UnixSocket = socket
+	return conf
+}
+
+func (conf *Config) SetDialContext(dial DialContextFunc) *Config {
+	conf.DialContext = dial
+	return conf
+}
+
+func (conf *Config) SetLocalAddr(localAddr string) *Config {
+	conf.LocalAddr = localAddr
+	return conf
+}
+
+func (conf *Config) SetPreferIP(version string) *Config {
+	conf.PreferIP = version
+	return conf
+}
+
 func (conf *Config) EnableHTTP2(enable bool) *Config {
 	conf.HTTP2 = enable
 	return conf
 }
+
+func (conf *Config) SetRetry(policy *RetryPolicy) *Config {
+	conf.Retry = policy
+	return conf
+}
+
+func (conf *Config) SetMaxIdleConns(maxIdleConns, maxIdleConnsPerHost int) *Config {
+	conf.MaxIdleConns = maxIdleConns
+	conf.MaxIdleConnsPerHost = maxIdleConnsPerHost
+	return conf
+}
+
+func (conf *Config) SetMaxConnsPerHost(maxConnsPerHost int) *Config {
+	conf.MaxConnsPerHost = maxConnsPerHost
+	return conf
+}
+
+func (conf *Config) SetIdleConnTimeout(idleConnTimeout int) *Config {
+	conf.IdleConnTimeout = idleConnTimeout
+	return conf
+}
+
+func (conf *Config) SetTLSConfig(tlsConfig *TLSConfig) *Config {
+	conf.TLS = tlsConfig
+	return conf
+}
+
+func (conf *Config) SetJsonOptions(opts *JsonOptions) *Config {
+	conf.JsonOptions = opts
+	return conf
+}
+
+func (conf *Config) SetCompress(compress string) *Config {
+	conf.Compress = compress
+	return conf
+}
+
+func (conf *Config) SetKeepRawContent(enable bool) *Config {
+	conf.KeepRawContent = enable
+	return conf
+}
+
+// SetCharset 设置默认请求数据字符集及响应文本字符集
+func (conf *Config) SetCharset(requestCharset, responseCharset string) *Config {
+	conf.RequestCharset = requestCharset
+	conf.ResponseCharset = responseCharset
+	return conf
+}
+
+func (conf *Config) SetUseNumber(enable bool) *Config {
+	conf.UseNumber = enable
+	return conf
+}
+
+func (conf *Config) SetUploadProgress(callback ProgressFunc) *Config {
+	conf.UploadProgress = callback
+	return conf
+}
+
+func (conf *Config) SetDownloadProgress(callback ProgressFunc) *Config {
+	conf.DownloadProgress = callback
+	return conf
+}
+
+// SetRateLimit 设置上传及下载限速，单位 字节/秒，0表示不限速
+func (conf *Config) SetRateLimit(uploadLimit, downloadLimit int64) *Config {
+	conf.UploadLimit = uploadLimit
+	conf.DownloadLimit = downloadLimit
+	return conf
+}
@@ -0,0 +1,207 @@
+package go_requests
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const maxRedirects = 10 // 最大重定向次数, 与 http.Client 默认值一致
+
+// 查找netrc文件, 优先使用NETRC环境变量, 否则为用户目录下的.netrc(Windows下也查找_netrc)
+func netrcPath() string {
+	if path := os.Getenv("NETRC"); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	for _, name := range []string{".netrc", "_netrc"} {
+		path := filepath.Join(home, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// 从netrc文件中查找主机的用户名及密码, 未找到主机时使用default, 文件不存在时返回nil
+func lookupNetrc(host string) ([]string, error) {
+	path := netrcPath()
+	if path == "" {
+		return nil, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取netrc文件出错: %w", err)
+	}
+	return parseNetrc(string(data), host), nil
+}
+
+// 解析netrc内容, 支持machine、default、login、password、account、macdef及#注释
+func parseNetrc(data, host string) []string {
+	var tokens []string
+	lines := strings.Split(data, "\n")
+	for i := 0; i < len(lines); i++ {
+		for _, field := range strings.Fields(lines[i]) {
+			if strings.HasPrefix(field, "#") {
+				break
+			}
+			if field == "macdef" {
+				// 宏定义直到空行结束
+				for i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" {
+					i++
+				}
+				break
+			}
+			tokens = append(tokens, field)
+		}
+	}
+	var found, fallback []string
+	var current []string // 当前machine或default的login及password, 不需要时为nil
+	for i := 0; i < len(tokens); i++ {
+		var value string
+		if i+1 < len(tokens) {
+			value = tokens[i+1]
+		}
+		switch tokens[i] {
+		case "machine":
+			current = nil
+			if value == host && found == nil {
+				current = []string{"", ""}
+				found = current
+			}
+			i++
+		case "default":
+			current = nil
+			if fallback == nil {
+				current = []string{"", ""}
+				fallback = current
+			}
+		case "login":
+			if current != nil {
+				current[0] = value
+			}
+			i++
+		case "password":
+			if current != nil {
+				current[1] = value
+			}
+			i++
+		case "account":
+			i++
+		}
+	}
+	if found != nil {
+		return found
+	}
+	return fallback
+}
+
+// 调用凭证助手获取用户名及密码, 输入输出格式与git credential相同:
+// 标准输入为 protocol=https、host=example.com 及 path=路径, 标准输出中读取 username= 及 password=
+func runCredentialHelper(ctx context.Context, command string, u *url.URL) ([]string, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, nil
+	}
+	input := fmt.Sprintf("protocol=%s\nhost=%s\npath=%s\n\n", u.Scheme, u.Host, strings.TrimPrefix(u.Path, "/"))
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(input)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("凭证助手 \"%s\" 执行失败: %w %s", command, err, strings.TrimSpace(stderr.String()))
+	}
+	credentials := []string{"", ""}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		key, value, ok := cutString(scanner.Text(), "=")
+		switch {
+		case !ok:
+		case key == "username":
+			credentials[0] = value
+		case key == "password":
+			credentials[1] = value
+		}
+	}
+	if credentials[0] == "" && credentials[1] == "" {
+		return nil, nil
+	}
+	return credentials, nil
+}
+
+// 按Netrc及CredentialHelper查找URL主机的用户名及密码, 未启用或未找到时返回nil
+func (req *Request) lookupCredentials(ctx context.Context, u *url.URL) ([]string, error) {
+	if req.Netrc {
+		credentials, err := lookupNetrc(u.Hostname())
+		if err != nil || credentials != nil {
+			return credentials, err
+		}
+	}
+	if req.CredentialHelper != "" {
+		return runCredentialHelper(ctx, req.CredentialHelper, u)
+	}
+	return nil, nil
+}
+
+// 未设置Auth、Authorization请求头及其他认证方式时, 从netrc或凭证助手获取BasicAuth用户名及密码
+func (req *Request) setCredentials(r *http.Request) error {
+	if len(req.Auth) == 2 || req.hasHeader("Authorization") || req.authenticator() != nil {
+		return nil
+	}
+	credentials, err := req.lookupCredentials(r.Context(), r.URL)
+	if err != nil || credentials == nil {
+		return err
+	}
+	r.SetBasicAuth(credentials[0], credentials[1])
+	return nil
+}
+
+// 处理重定向, 重定向到其他主机(含端口)或从https降级为http时不携带原请求的Authorization、Cookie请求头
+// 及Authenticator添加的请求头(如API Key), 启用Netrc或CredentialHelper时按新主机重新查找用户名及密码
+func (req *Request) checkRedirect(r *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return errors.New("重定向次数超过10次")
+	}
+	original := via[0].URL
+	if r.URL.Host == original.Host && !(original.Scheme == "https" && r.URL.Scheme == "http") {
+		return nil
+	}
+	r.Header.Del("Authorization")
+	r.Header.Del("Cookie") // 会话Cookie存储中的Cookie由 http.Client 按新主机重新添加
+	for key := range req.authHeaders {
+		r.Header.Del(key)
+	}
+	if len(req.Auth) == 2 || req.hasHeader("Authorization") || req.authenticator() != nil {
+		return nil // 显式设置的认证信息只属于原主机
+	}
+	credentials, err := req.lookupCredentials(r.Context(), r.URL)
+	if err != nil || credentials == nil {
+		return err
+	}
+	r.SetBasicAuth(credentials[0], credentials[1])
+	return nil
+}
+
+// 按第一个sep切分字符串
+func cutString(s, sep string) (string, string, bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
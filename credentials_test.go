@@ -0,0 +1,167 @@
+package go_requests
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 测试解析netrc内容
+func TestParseNetrc(t *testing.T) {
+	data := `# 注释
+machine example.com login kevin password 123456
+machine api.example.com
+  login api
+  account ignored
+  password secret
+macdef init
+  cd /pub
+  machine evil.com login evil password evil
+
+default login anonymous password guest
+`
+	cases := map[string]string{
+		"example.com":     "kevin:123456",
+		"api.example.com": "api:secret",
+		"evil.com":        "anonymous:guest",
+		"other.com":       "anonymous:guest",
+	}
+	for host, expected := range cases {
+		if credentials := parseNetrc(data, host); strings.Join(credentials, ":") != expected {
+			t.Errorf("%s: 期望 %s, 实际 %v", host, expected, credentials)
+		}
+	}
+	if credentials := parseNetrc("machine example.com login a password b", "other.com"); credentials != nil {
+		t.Errorf("未配置default时应返回nil, 实际 %v", credentials)
+	}
+}
+
+// 返回认证信息的服务, 依次为Authorization、X-Token及Cookie请求头
+func newEchoAuthServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(echoAuth))
+}
+
+func echoAuth(w http.ResponseWriter, r *http.Request) {
+	_, _ = w.Write([]byte(r.Header.Get("Authorization") + r.Header.Get("X-Token") + r.Header.Get("Cookie")))
+}
+
+// 测试从NETRC环境变量指定的文件中查找用户名及密码
+func TestNetrc(t *testing.T) {
+	server := newEchoAuthServer()
+	defer server.Close()
+	path := filepath.Join(t.TempDir(), "netrc")
+	_ = ioutil.WriteFile(path, []byte("machine 127.0.0.1 login kevin password 123456\n"), 0600)
+	t.Setenv("NETRC", path)
+
+	resp, err := NewRequestWithConfig(NewConfig().SetNetrc(true), "GET", server.URL).Do()
+	if err != nil || resp.Text != "Basic a2V2aW46MTIzNDU2" {
+		t.Errorf("期望使用netrc中的用户名及密码, 实际 %v %v", resp, err)
+	}
+	resp, _ = NewRequest("GET", server.URL).SetNetrc(true).SetBasicAuth([]string{"a", "b"}).Do()
+	if resp.Text != "Basic YTpi" {
+		t.Errorf("已设置Auth时不应使用netrc, 实际 %s", resp.Text)
+	}
+	resp, _ = NewRequest("GET", server.URL).Do()
+	if resp.Text != "" {
+		t.Errorf("未启用Netrc时不应发送认证信息, 实际 %s", resp.Text)
+	}
+}
+
+// 测试调用凭证助手获取用户名及密码
+func TestCredentialHelper(t *testing.T) {
+	server := newEchoAuthServer()
+	defer server.Close()
+	dir := t.TempDir()
+	script := filepath.Join(dir, "helper.sh")
+	_ = ioutil.WriteFile(script, []byte("#!/bin/sh\ncat > "+filepath.Join(dir, "input")+"\necho username=kevin\necho password=123456\n"), 0700)
+
+	resp, err := NewRequest("GET", server.URL+"/repo.git").SetCredentialHelper("sh " + script).Do()
+	if err != nil || resp.Text != "Basic a2V2aW46MTIzNDU2" {
+		t.Fatalf("期望使用凭证助手返回的用户名及密码, 实际 %v %v", resp, err)
+	}
+	input, _ := ioutil.ReadFile(filepath.Join(dir, "input"))
+	expected := "protocol=http\nhost=" + strings.TrimPrefix(server.URL, "http://") + "\npath=repo.git\n\n"
+	if string(input) != expected {
+		t.Errorf("凭证助手输入错误: %q", input)
+	}
+
+	_, err = NewRequest("GET", server.URL).SetCredentialHelper("sh " + filepath.Join(dir, "missing.sh")).Do()
+	if !errors.Is(err, ErrAuth) {
+		t.Errorf("期望ErrAuth, 实际 %v", err)
+	}
+}
+
+// 测试请求文件及会话文件不能设置凭证助手命令
+func TestCredentialHelperNotFromJson(t *testing.T) {
+	req := GetRequestFromJson([]byte(`{"url": "http://example.com", "credential_helper": "sh -c id", "config": {"credential_helper": "sh -c id"}}`))
+	if req.CredentialHelper != "" || req.Config == nil || req.Config.CredentialHelper != "" {
+		t.Errorf("不应从JSON读取凭证助手命令: %q %+v", req.CredentialHelper, req.Config)
+	}
+	path := filepath.Join(t.TempDir(), "session.json")
+	_ = ioutil.WriteFile(path, []byte(`{"config": {"credential_helper": "sh -c id"}}`), 0600)
+	session, err := LoadSession(path)
+	if err != nil || session.Config.CredentialHelper != "" {
+		t.Errorf("不应从会话文件读取凭证助手命令: %v %+v", err, session)
+	}
+}
+
+// 在自定义请求头中添加令牌的认证方式
+type headerAuth struct{}
+
+func (a *headerAuth) Authorize(r *http.Request) error {
+	r.Header.Set("X-Token", "secret")
+	return nil
+}
+
+func (a *headerAuth) Challenge(r *http.Request, res *http.Response) bool {
+	return false
+}
+
+// 测试重定向到其他主机时不携带认证信息
+func TestRedirectCredentials(t *testing.T) {
+	other := newEchoAuthServer()
+	defer other.Close()
+	otherUrl := strings.Replace(other.URL, "127.0.0.1", "localhost", 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/other":
+			http.Redirect(w, r, otherUrl, http.StatusFound)
+		case "/same":
+			http.Redirect(w, r, "/echo", http.StatusFound)
+		default:
+			echoAuth(w, r)
+		}
+	}))
+	defer server.Close()
+	path := filepath.Join(t.TempDir(), "netrc")
+	_ = ioutil.WriteFile(path, []byte("machine 127.0.0.1 login kevin password 123456\n"), 0600)
+	t.Setenv("NETRC", path)
+
+	cases := []struct {
+		req      *Request
+		expected string
+	}{
+		{NewRequest("GET", server.URL+"/same").SetBasicAuth([]string{"a", "b"}), "Basic YTpi"},
+		{NewRequest("GET", server.URL+"/other").SetBasicAuth([]string{"a", "b"}), ""},
+		{NewRequest("GET", server.URL+"/other").SetHeaders(map[string]string{"Authorization": "Bearer abc"}), ""},
+		{NewRequest("GET", server.URL+"/other").SetNetrc(true), ""},
+		{NewRequest("GET", server.URL+"/same").SetNetrc(true), "Basic a2V2aW46MTIzNDU2"},
+		{NewRequest("GET", server.URL+"/same").SetAuthenticator(&BearerAuth{Token: "abc"}), "Bearer abc"},
+		{NewRequest("GET", server.URL+"/other").SetAuthenticator(&BearerAuth{Token: "abc"}), ""},
+		{NewRequest("GET", server.URL+"/same").SetAuthenticator(&headerAuth{}).SetCookies(map[string]string{"sid": "abc"}), "secretsid=abc"},
+		{NewRequest("GET", server.URL+"/other").SetAuthenticator(&headerAuth{}).SetCookies(map[string]string{"sid": "abc"}), ""},
+	}
+	for i, c := range cases {
+		resp, err := c.req.Do()
+		if err != nil {
+			t.Fatalf("第%d个请求失败: %s", i+1, err)
+		}
+		if resp.Text != c.expected {
+			t.Errorf("第%d个请求期望 %q, 实际 %q", i+1, c.expected, resp.Text)
+		}
+	}
+}
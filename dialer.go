@@ -0,0 +1,121 @@
+package go_requests
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DialContextFunc 自定义拨号函数, 签名与 net.Dialer.DialContext 相同
+type DialContextFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// Dial 使用 context.Background() 拨号, 实现 golang.org/x/net/proxy.Dialer
+func (f DialContextFunc) Dial(network, addr string) (net.Conn, error) {
+	return f(context.Background(), network, addr)
+}
+
+// DialContext 实现 golang.org/x/net/proxy.ContextDialer
+func (f DialContextFunc) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	return f(ctx, network, addr)
+}
+
+// 解析 http+unix:// 地址, 主机部分为URL编码的Unix socket路径,
+// 如 "http+unix://%2Fvar%2Frun%2Fdocker.sock/info" 返回 "/var/run/docker.sock" 及 "http://localhost/info"
+func parseUnixUrl(rawUrl string) (string, string, bool, error) {
+	const prefix = "http+unix://"
+	if len(rawUrl) < len(prefix) || !strings.EqualFold(rawUrl[:len(prefix)], prefix) {
+		return "", rawUrl, false, nil
+	}
+	rest := rawUrl[len(prefix):]
+	end := strings.IndexAny(rest, "/?#")
+	if end < 0 {
+		end = len(rest)
+	}
+	socket, err := url.PathUnescape(rest[:end])
+	if err != nil {
+		return "", "", false, fmt.Errorf("解析Unix socket路径 \"%s\" 出错: %w", rest[:end], err)
+	}
+	if socket == "" {
+		return "", "", false, fmt.Errorf("\"%s\"缺少Unix socket路径", rawUrl)
+	}
+	return socket, "http://localhost" + rest[end:], true, nil
+}
+
+// Unix socket路径, 支持 "unix:///var/run/docker.sock" 及 "/var/run/docker.sock"
+func unixSocketPath(socket string) string {
+	if strings.HasPrefix(socket, "unix://") {
+		return strings.TrimPrefix(socket, "unix://")
+	}
+	return socket
+}
+
+// 按请求配置生成拨号函数:
+// 设置UnixSocket时所有连接都连接到该socket; 设置DialContext时使用自定义拨号函数, 否则按LocalAddr及PreferIP拨号
+func (req *Request) getDialer() (DialContextFunc, error) {
+	dial := req.DialContext
+	if dial == nil && req.Config != nil {
+		dial = req.Config.DialContext
+	}
+	if dial == nil {
+		dialer := &net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}
+		if req.LocalAddr != "" {
+			ip := net.ParseIP(req.LocalAddr)
+			if ip == nil {
+				return nil, fmt.Errorf("本地地址 \"%s\" 不是有效的IP", req.LocalAddr)
+			}
+			dialer.LocalAddr = &net.TCPAddr{IP: ip}
+		}
+		dial = dialer.DialContext
+		switch req.PreferIP {
+		case "":
+		case "ipv4", "ipv6":
+			dial = preferIPDialer(dial, req.PreferIP == "ipv6")
+		default:
+			return nil, fmt.Errorf("不支持的IP版本 \"%s\", 可选 \"ipv4\" 或 \"ipv6\"", req.PreferIP)
+		}
+	}
+	if req.UnixSocket != "" {
+		socket, next := unixSocketPath(req.UnixSocket), dial
+		dial = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return next(ctx, "unix", socket)
+		}
+	}
+	return dial, nil
+}
+
+// 解析域名后优先连接指定版本的IP地址, 失败时依次尝试其他地址
+func preferIPDialer(dial DialContextFunc, ipv6 bool) DialContextFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil || net.ParseIP(host) != nil {
+			return dial(ctx, network, addr)
+		}
+		ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		var preferred, others []net.IPAddr
+		for _, ip := range ips {
+			if (ip.IP.To4() == nil) == ipv6 {
+				preferred = append(preferred, ip)
+			} else {
+				others = append(others, ip)
+			}
+		}
+		var lastErr error
+		for _, ip := range append(preferred, others...) {
+			conn, err := dial(ctx, network, net.JoinHostPort(ip.String(), port))
+			if err == nil {
+				return conn, nil
+			}
+			lastErr = err
+		}
+		return nil, lastErr
+	}
+}
@@ -0,0 +1,120 @@
+package go_requests
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// 监听Unix socket的服务, 返回请求路径及Host
+func newUnixServer(t *testing.T) (*httptest.Server, string) {
+	socket := filepath.Join(t.TempDir(), "test.sock")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("不支持Unix socket: %s", err)
+	}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Host + " " + r.URL.RequestURI()))
+	}))
+	server.Listener = ln
+	server.Start()
+	return server, socket
+}
+
+// 测试通过Unix socket发送请求
+func TestUnixSocket(t *testing.T) {
+	server, socket := newUnixServer(t)
+	defer server.Close()
+
+	resp, err := NewRequest("GET", "http://docker/v1.41/info").SetUnixSocket(socket).Do()
+	if err != nil || resp.Text != "docker /v1.41/info" {
+		t.Fatalf("期望通过Unix socket请求, 实际 %v %v", resp, err)
+	}
+	config := NewConfig().SetUnixSocket("unix://" + socket).SetBaseUrl("http://localhost")
+	resp, err = NewRequestWithConfig(config, "GET", "/containers/json").SetParams(map[string]string{"all": "1"}).Do()
+	if err != nil || resp.Text != "localhost /containers/json?all=1" {
+		t.Fatalf("期望使用Config中的Unix socket, 实际 %v %v", resp, err)
+	}
+	resp, err = NewRequest("GET", "http+unix://"+url.PathEscape(socket)+"/info?a=1").Do()
+	if err != nil || resp.Text != "localhost /info?a=1" {
+		t.Fatalf("期望解析http+unix地址, 实际 %v %v", resp, err)
+	}
+	// 连接Unix socket时不使用代理
+	resp, err = NewRequest("GET", "http://docker/info").SetUnixSocket(socket).SetProxy("http://127.0.0.1:1").Do()
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Errorf("连接Unix socket时不应使用代理, 实际 %v %v", resp, err)
+	}
+}
+
+// 测试解析http+unix地址
+func TestParseUnixUrl(t *testing.T) {
+	socket, rawUrl, ok, err := parseUnixUrl("http+unix://%2Fvar%2Frun%2Fdocker.sock/v1.41/info?all=1")
+	if err != nil || !ok || socket != "/var/run/docker.sock" || rawUrl != "http://localhost/v1.41/info?all=1" {
+		t.Errorf("解析结果错误: %s %s %v %v", socket, rawUrl, ok, err)
+	}
+	if _, rawUrl, ok, _ = parseUnixUrl("http://example.com/"); ok || rawUrl != "http://example.com/" {
+		t.Errorf("非http+unix地址应原样返回: %s", rawUrl)
+	}
+	if _, _, _, err = parseUnixUrl("http+unix:///info"); err == nil {
+		t.Error("期望缺少socket路径错误")
+	}
+}
+
+// 测试自定义拨号函数, 所有连接由自定义函数建立
+func TestDialContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Host))
+	}))
+	defer server.Close()
+
+	var dials int32
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		atomic.AddInt32(&dials, 1)
+		return (&net.Dialer{}).DialContext(ctx, network, server.Listener.Addr().String())
+	}
+	config := NewConfig().SetDialContext(dial)
+	resp, err := NewRequestWithConfig(config, "GET", "http://sidecar.local/").Do()
+	if err != nil || resp.Text != "sidecar.local" || atomic.LoadInt32(&dials) != 1 {
+		t.Fatalf("期望使用自定义拨号函数, 实际 %v %v %d", resp, err, dials)
+	}
+	// 自定义拨号函数也用于连接SOCKS5代理
+	s := newSocks5Server(t, "", "")
+	defer s.Close()
+	var proxyDials int32
+	dial = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if addr == s.Addr().String() {
+			atomic.AddInt32(&proxyDials, 1)
+		}
+		return (&net.Dialer{}).DialContext(ctx, network, addr)
+	}
+	resp, err = NewRequest("GET", server.URL).SetDialContext(dial).SetProxy("socks5h://" + s.Addr().String()).Do()
+	if err != nil || resp.StatusCode != http.StatusOK || atomic.LoadInt32(&proxyDials) != 1 {
+		t.Errorf("期望通过自定义拨号函数连接代理, 实际 %v %v %d", resp, err, proxyDials)
+	}
+}
+
+// 测试绑定本地地址及优先使用IPv4
+func TestLocalAddrAndPreferIP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.RemoteAddr))
+	}))
+	defer server.Close()
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+
+	resp, err := NewRequest("GET", "http://localhost:"+port).SetLocalAddr("127.0.0.1").SetPreferIP("ipv4").Do()
+	if err != nil || !strings.HasPrefix(resp.Text, "127.0.0.1:") {
+		t.Fatalf("期望从127.0.0.1连接, 实际 %v %v", resp, err)
+	}
+	if _, err := NewRequest("GET", server.URL).SetLocalAddr("invalid").Do(); err == nil {
+		t.Error("期望无效本地地址错误")
+	}
+	if _, err := NewRequest("GET", server.URL).SetPreferIP("ipv5").Do(); err == nil {
+		t.Error("期望不支持的IP版本错误")
+	}
+}
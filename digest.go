@@ -0,0 +1,207 @@
+package go_requests
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// 支持的摘要算法, 按安全性从低到高排列
+var digestAlgorithms = []string{"MD5", "SHA-256", "SHA-512-256"}
+
+var digestHashes = map[string]func() hash.Hash{
+	"MD5":         md5.New,
+	"SHA-256":     sha256.New,
+	"SHA-512-256": sha512.New512_256,
+}
+
+// DigestAuth HTTP摘要认证(RFC 7616), 支持MD5、SHA-256、SHA-512-256及其-sess变体, qop支持auth及auth-int
+// 收到401质询后自动计算摘要并重发请求, 同一DigestAuth的后续请求复用服务器nonce并递增nc, 可并发使用
+type DigestAuth struct {
+	Username string `json:"username"` // 用户名
+	Password string `json:"password"` // 密码
+
+	mu    sync.Mutex
+	state *digestChallenge // 最近一次质询
+	nc    uint32           // 当前nonce的请求计数
+}
+
+// 服务器Digest质询
+type digestChallenge struct {
+	host      string // 质询所属主机, 仅向该主机预先发送认证信息
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string // 算法名称, 如 "SHA-256"、"MD5-sess"
+	qop       string // 选用的qop, 服务器未提供时为空
+	userhash  bool   // 是否发送用户名摘要
+}
+
+func NewDigestAuth(username, password string) *DigestAuth {
+	return &DigestAuth{Username: username, Password: password}
+}
+
+// Authorize 使用缓存的质询为请求添加Authorization请求头, 尚未收到质询或主机不同时不处理
+func (d *DigestAuth) Authorize(r *http.Request) error {
+	d.mu.Lock()
+	c := d.state
+	if c == nil || c.host != r.URL.Host {
+		d.mu.Unlock()
+		return nil
+	}
+	d.nc++
+	nc := d.nc
+	d.mu.Unlock()
+
+	newHash := digestHashes[strings.TrimSuffix(c.algorithm, "-sess")]
+	h := func(data string) string {
+		sum := newHash()
+		sum.Write([]byte(data))
+		return hex.EncodeToString(sum.Sum(nil))
+	}
+	cnonce, err := newCnonce()
+	if err != nil {
+		return err
+	}
+	uri := r.URL.RequestURI()
+	ha1 := h(d.Username + ":" + c.realm + ":" + d.Password)
+	if strings.HasSuffix(c.algorithm, "-sess") {
+		ha1 = h(ha1 + ":" + c.nonce + ":" + cnonce)
+	}
+	ha2 := h(r.Method + ":" + uri)
+	if c.qop == "auth-int" {
+		body, err := readBody(r)
+		if err != nil {
+			return err
+		}
+		ha2 = h(r.Method + ":" + uri + ":" + h(string(body)))
+	}
+	var response string
+	if c.qop == "" {
+		response = h(ha1 + ":" + c.nonce + ":" + ha2)
+	} else {
+		response = h(fmt.Sprintf("%s:%s:%08x:%s:%s:%s", ha1, c.nonce, nc, cnonce, c.qop, ha2))
+	}
+
+	username := d.Username
+	if c.userhash {
+		username = h(d.Username + ":" + c.realm)
+	}
+	params := []string{
+		"username=" + quote(username),
+		"realm=" + quote(c.realm),
+		"nonce=" + quote(c.nonce),
+		"uri=" + quote(uri),
+		"algorithm=" + c.algorithm,
+		"response=" + quote(response),
+	}
+	if c.opaque != "" {
+		params = append(params, "opaque="+quote(c.opaque))
+	}
+	if c.qop != "" {
+		params = append(params, "qop="+c.qop, fmt.Sprintf("nc=%08x", nc), "cnonce="+quote(cnonce))
+	}
+	if c.userhash {
+		params = append(params, "userhash=true")
+	}
+	r.Header.Set("Authorization", "Digest "+strings.Join(params, ", "))
+	return nil
+}
+
+// Challenge 处理401响应中的Digest质询, 选用最安全的受支持算法, 返回是否可以重新认证
+// 请求数据无法重复读取时不选用auth-int
+func (d *DigestAuth) Challenge(r *http.Request, res *http.Response) bool {
+	if res.StatusCode != http.StatusUnauthorized {
+		return false
+	}
+	var best *digestChallenge
+	rank := -1
+	for _, params := range parseAuthParams(res.Header.Values("WWW-Authenticate"), "Digest") {
+		c := &digestChallenge{
+			host:     r.URL.Host,
+			realm:    params["realm"],
+			nonce:    params["nonce"],
+			opaque:   params["opaque"],
+			userhash: strings.EqualFold(params["userhash"], "true"),
+		}
+		algorithm := strings.ToUpper(params["algorithm"])
+		if algorithm == "" {
+			algorithm = "MD5"
+		}
+		c.algorithm = strings.TrimSuffix(algorithm, "-SESS")
+		index := indexOf(digestAlgorithms, c.algorithm)
+		if c.nonce == "" || index < 0 {
+			continue
+		}
+		if c.algorithm != algorithm {
+			c.algorithm += "-sess"
+		}
+		if qop, ok := params["qop"]; ok {
+			if c.qop = selectQop(qop, r.GetBody != nil || r.Body == nil || r.Body == http.NoBody); c.qop == "" {
+				continue
+			}
+		}
+		if index > rank {
+			best, rank = c, index
+		}
+	}
+	if best == nil {
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.state = best
+	d.nc = 0
+	return true
+}
+
+func (d *DigestAuth) Scheme() string {
+	return "Digest"
+}
+
+// 选用qop, 优先使用auth, 请求数据可重复读取时才能使用auth-int
+func selectQop(qop string, rereadable bool) string {
+	var options []string
+	for _, item := range strings.Split(qop, ",") {
+		options = append(options, strings.ToLower(strings.TrimSpace(item)))
+	}
+	if indexOf(options, "auth") >= 0 {
+		return "auth"
+	}
+	if indexOf(options, "auth-int") >= 0 && rereadable {
+		return "auth-int"
+	}
+	return ""
+}
+
+// 通过GetBody读取完整请求数据, 不影响请求本身的Body
+func readBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	if r.GetBody == nil {
+		return nil, fmt.Errorf("请求数据无法重复读取")
+	}
+	body, err := r.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return ioutil.ReadAll(body)
+}
+
+func newCnonce() (string, error) {
+	data := make([]byte, 16)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(data), nil
+}
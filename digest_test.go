@@ -0,0 +1,149 @@
+package go_requests
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Digest认证服务, 校验摘要并记录收到的nc, 认证失败时返回质询
+type digestServer struct {
+	*httptest.Server
+	algorithm string
+	qop       string
+
+	mu         sync.Mutex
+	challenges int      // 返回质询次数
+	ncs        []string // 认证成功的nc
+}
+
+func newDigestServer(algorithm, qop string) *digestServer {
+	s := &digestServer{algorithm: algorithm, qop: qop}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if s.verify(r, body) {
+			_, _ = w.Write(body)
+			return
+		}
+		s.mu.Lock()
+		s.challenges++
+		s.mu.Unlock()
+		challenge := fmt.Sprintf(`Digest realm="test@example.com", nonce="abc123", opaque="xyz", algorithm=%s`, algorithm)
+		if qop != "" {
+			challenge += fmt.Sprintf(`, qop="%s"`, qop)
+		}
+		w.Header().Add("WWW-Authenticate", `Basic realm="test"`)
+		w.Header().Add("WWW-Authenticate", challenge)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	return s
+}
+
+func (s *digestServer) verify(r *http.Request, body []byte) bool {
+	list := parseAuthParams([]string{r.Header.Get("Authorization")}, "Digest")
+	if len(list) != 1 {
+		return false
+	}
+	params := list[0]
+	h := func(data string) string {
+		sum := digestHashes[strings.TrimSuffix(s.algorithm, "-sess")]()
+		sum.Write([]byte(data))
+		return fmt.Sprintf("%x", sum.Sum(nil))
+	}
+	ha1 := h("kevin:test@example.com:secret")
+	if strings.HasSuffix(s.algorithm, "-sess") {
+		ha1 = h(ha1 + ":abc123:" + params["cnonce"])
+	}
+	ha2 := h(r.Method + ":" + params["uri"])
+	if params["qop"] == "auth-int" {
+		ha2 = h(r.Method + ":" + params["uri"] + ":" + h(string(body)))
+	}
+	expected := h(ha1 + ":abc123:" + ha2)
+	if s.qop != "" {
+		expected = h(strings.Join([]string{ha1, "abc123", params["nc"], params["cnonce"], params["qop"], ha2}, ":"))
+	}
+	if params["username"] != "kevin" || params["opaque"] != "xyz" || params["uri"] != r.URL.RequestURI() || params["response"] != expected {
+		return false
+	}
+	s.mu.Lock()
+	s.ncs = append(s.ncs, params["nc"])
+	s.mu.Unlock()
+	return true
+}
+
+// 测试各算法及qop的Digest认证
+func TestDigestAuth(t *testing.T) {
+	cases := []struct {
+		algorithm string
+		qop       string
+	}{
+		{"MD5", "auth"},
+		{"MD5-sess", "auth"},
+		{"SHA-256", "auth,auth-int"},
+		{"SHA-512-256", "auth"},
+		{"MD5", ""},
+	}
+	for _, c := range cases {
+		server := newDigestServer(c.algorithm, c.qop)
+		resp, err := NewRequest("GET", server.URL+"/dir/index.html?a=1").SetDigestAuth("kevin", "secret").Do()
+		server.Close()
+		if err != nil {
+			t.Fatalf("%s %s: 请求失败: %s", c.algorithm, c.qop, err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("%s %s: 期望200, 实际 %d", c.algorithm, c.qop, resp.StatusCode)
+		}
+	}
+}
+
+// 测试qop=auth-int对请求数据计算摘要
+func TestDigestAuthInt(t *testing.T) {
+	server := newDigestServer("SHA-256", "auth-int")
+	defer server.Close()
+
+	resp, err := NewRequest("POST", server.URL).SetDigestAuth("kevin", "secret").SetRawData("hello").Do()
+	if err != nil {
+		t.Fatalf("请求失败: %s", err)
+	}
+	if resp.StatusCode != http.StatusOK || resp.Text != "hello" {
+		t.Errorf("期望200及原样返回的请求数据, 实际 %d %s", resp.StatusCode, resp.Text)
+	}
+}
+
+// 测试会话及Config中的请求复用nonce并递增nc
+func TestDigestAuthReuseNonce(t *testing.T) {
+	server := newDigestServer("MD5", "auth")
+	defer server.Close()
+
+	session := NewSession(NewConfig().SetDigestAuth("kevin", "secret"))
+	for i := 0; i < 3; i++ {
+		resp := session.Get(server.URL, nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("第%d次请求期望200, 实际 %d", i+1, resp.StatusCode)
+		}
+	}
+	if server.challenges != 1 {
+		t.Errorf("期望只收到1次质询, 实际 %d", server.challenges)
+	}
+	if strings.Join(server.ncs, ",") != "00000001,00000002,00000003" {
+		t.Errorf("nc错误: %v", server.ncs)
+	}
+}
+
+// 测试密码错误时只重发一次并返回401
+func TestDigestAuthWrongPassword(t *testing.T) {
+	server := newDigestServer("MD5", "auth")
+	defer server.Close()
+
+	resp, err := NewRequest("GET", server.URL).SetDigestAuth("kevin", "wrong").Do()
+	if err != nil {
+		t.Fatalf("请求失败: %s", err)
+	}
+	if resp.StatusCode != http.StatusUnauthorized || server.challenges != 2 {
+		t.Errorf("期望401及2次质询, 实际 %d %d", resp.StatusCode, server.challenges)
+	}
+}
@@ -0,0 +1,233 @@
+package go_requests
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// ErrChecksum 下载文件校验值不匹配
+var ErrChecksum = errors.New("文件校验值不匹配")
+
+var checksumHashes = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// DownloadOptions 下载配置
+type DownloadOptions struct {
+	Progress  ProgressFunc // 下载进度回调
+	Checksum  string       // 期望的文件校验值, 格式为 "算法:十六进制值", 如 "sha256:9f86d0...", 支持md5、sha1、sha256、sha512
+	Resume    bool         // 断点续传, 下载中断时保留 .part 临时文件, 下次下载时通过Range请求继续
+	Parallel  int          // 并发分片数, 大于1时按Range分片并发下载, 服务器不支持Range时回退为单连接下载
+	ChunkSize int64        // 并发下载的分片大小, 默认4MB
+}
+
+// 解析校验值配置
+func parseChecksum(checksum string) (hash.Hash, string, error) {
+	if checksum == "" {
+		return nil, "", nil
+	}
+	parts := strings.SplitN(checksum, ":", 2)
+	newHash, ok := checksumHashes[strings.ToLower(parts[0])]
+	if len(parts) != 2 || !ok {
+		return nil, "", fmt.Errorf("不支持的校验值 \"%s\", 格式应为 \"算法:十六进制值\"", checksum)
+	}
+	return newHash(), strings.ToLower(parts[1]), nil
+}
+
+// 校验文件内容
+func verifyChecksum(path, checksum string) error {
+	h, expected, err := parseChecksum(checksum)
+	if h == nil || err != nil {
+		return err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if _, err = io.Copy(h, file); err != nil {
+		return err
+	}
+	if actual := hex.EncodeToString(h.Sum(nil)); actual != expected {
+		return fmt.Errorf("%w: 期望 %s, 实际 %s", ErrChecksum, expected, actual)
+	}
+	return nil
+}
+
+// 打开下载临时文件, 保留前offset个字节并从offset处继续写入
+func openPart(partPath string, offset int64) (*os.File, error) {
+	file, err := os.OpenFile(partPath, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err = file.Truncate(offset); err == nil {
+		_, err = file.Seek(offset, io.SeekStart)
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+// 关闭并校验临时文件, 成功后重命名为目标文件
+func finishPart(file *os.File, path, checksum string) error {
+	err := file.Sync()
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = verifyChecksum(file.Name(), checksum)
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// 从offset处写入临时文件, 校验后重命名为目标文件, 失败时删除临时文件(断点续传时保留)
+func saveFile(path string, body io.Reader, progress Progress, opts *DownloadOptions) error {
+	if opts == nil {
+		opts = &DownloadOptions{}
+	}
+	if _, _, err := parseChecksum(opts.Checksum); err != nil {
+		return err
+	}
+	partPath := path + ".part"
+	file, err := openPart(partPath, progress.Transferred)
+	if err != nil {
+		return err
+	}
+	counter := newProgressCounter(progress, opts.Progress)
+	if _, err = io.Copy(&progressWriter{writer: file, counter: counter}, body); err != nil {
+		file.Close()
+		if !opts.Resume {
+			os.Remove(partPath)
+		}
+		return err
+	}
+	if err = finishPart(file, path, opts.Checksum); err != nil {
+		os.Remove(partPath)
+		return err
+	}
+	return nil
+}
+
+// SaveTo 保存响应数据到文件, 流式响应边读取边写入, 完成并校验后才重命名为目标文件
+func (res *Response) SaveTo(path string, opts *DownloadOptions) error {
+	if res.Body == nil {
+		return saveFile(path, bytes.NewReader(res.Content), Progress{Total: int64(len(res.Content))}, opts)
+	}
+	defer res.Body.Close()
+	return saveFile(path, res.Body, Progress{Total: res.ContentLength}, opts)
+}
+
+// Download 下载到文件, 响应数据不读入内存
+func (req *Request) Download(path string, opts *DownloadOptions) (*Response, error) {
+	return req.DownloadContext(context.Background(), path, opts)
+}
+
+// DownloadContext 使用ctx下载到文件, 响应状态码不是2xx时不保存文件并返回错误
+// 并发下载时返回的是探测文件长度的HEAD请求响应
+func (req *Request) DownloadContext(ctx context.Context, path string, opts *DownloadOptions) (*Response, error) {
+	if opts == nil {
+		opts = &DownloadOptions{}
+	}
+	if _, _, err := parseChecksum(opts.Checksum); err != nil {
+		return nil, err
+	}
+	if opts.Parallel > 1 {
+		return req.downloadParallel(ctx, path, opts)
+	}
+	return req.download(ctx, path, opts)
+}
+
+// 单连接下载, 断点续传时通过Range及If-Range请求剩余部分, 使用请求的副本, 不修改原请求
+func (req *Request) download(ctx context.Context, path string, opts *DownloadOptions) (*Response, error) {
+	req = req.clone()
+	partPath, statePath := path+".part", path+".part.json"
+	rawUrl := req.fullUrl() // 保存及比较续传状态时使用相同的完整地址
+	var offset int64
+	state := loadDownloadState(statePath)
+	if opts.Resume && state != nil && state.Url == rawUrl && state.validator() != "" {
+		if info, err := os.Stat(partPath); err == nil && info.Size() > 0 {
+			offset = info.Size()
+			req.SetHeaders(map[string]string{
+				"Range":    fmt.Sprintf("bytes=%d-", offset),
+				"If-Range": state.validator(),
+			})
+		}
+	}
+	req.Stream = true
+	resp, err := req.DoContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	total := resp.ContentLength
+	switch {
+	case offset > 0 && resp.StatusCode == http.StatusPartialContent:
+		start, _, size, err := parseContentRange(resp.Headers["Content-Range"])
+		if err != nil || start != offset || (state.Size >= 0 && size != state.Size) {
+			resp.Close()
+			removeDownload(path)
+			return resp, req.newError(ErrReadBody, fmt.Errorf("%w: Content-Range \"%s\"", ErrRangeMismatch, resp.Headers["Content-Range"]))
+		}
+		total = size
+	case offset > 0 && resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		// 临时文件已是完整文件
+		resp.Close()
+		if _, _, size, err := parseContentRange(resp.Headers["Content-Range"]); err != nil || size != offset {
+			removeDownload(path)
+			return resp, req.newError(ErrReadBody, fmt.Errorf("%w: 状态码 %d", ErrRangeMismatch, resp.StatusCode))
+		}
+		file, err := os.OpenFile(partPath, os.O_WRONLY, 0644)
+		if err == nil {
+			err = finishPart(file, path, opts.Checksum)
+		}
+		if err != nil {
+			removeDownload(path)
+			return resp, err
+		}
+		os.Remove(statePath)
+		return resp, nil
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		resp.Close()
+		return resp, fmt.Errorf("下载失败: %d %s", resp.StatusCode, resp.Reason)
+	default:
+		offset = 0 // 服务器返回完整内容, 重新下载
+	}
+	if opts.Resume {
+		state = newDownloadState(rawUrl, resp, total)
+		if err = state.save(statePath); err != nil {
+			resp.Close()
+			return resp, err
+		}
+	}
+	defer resp.Close()
+	if err = saveFile(path, resp.Body, Progress{Transferred: offset, Total: total}, opts); err != nil {
+		if errors.Is(err, ErrChecksum) || !opts.Resume {
+			os.Remove(statePath)
+		}
+		if !errors.Is(err, ErrChecksum) {
+			err = req.newError(ErrReadBody, err)
+		}
+		return resp, err
+	}
+	os.Remove(statePath)
+	return resp, nil
+}
@@ -0,0 +1,310 @@
+package go_requests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrRangeMismatch 分片响应与请求的范围不一致, 或下载过程中文件已变化(ETag不一致)
+var ErrRangeMismatch = errors.New("分片响应范围或ETag不一致")
+
+const defaultChunkSize = 4 << 20 // 默认分片大小4MB
+
+// 断点续传状态, 保存在 path.part.json 中
+type downloadState struct {
+	Url          string  `json:"url"`           // 下载地址
+	ETag         string  `json:"etag"`          // 响应ETag
+	LastModified string  `json:"last_modified"` // 响应Last-Modified
+	Size         int64   `json:"size"`          // 文件总长度, 未知时为-1
+	ChunkSize    int64   `json:"chunk_size"`    // 并发下载分片大小
+	Done         []int64 `json:"done"`          // 并发下载已完成分片的起始位置
+}
+
+func newDownloadState(rawUrl string, resp *Response, size int64) *downloadState {
+	return &downloadState{
+		Url:          rawUrl,
+		ETag:         resp.Headers["Etag"],
+		LastModified: resp.Headers["Last-Modified"],
+		Size:         size,
+	}
+}
+
+// 读取断点续传状态, 不存在或无法解析时返回nil
+func loadDownloadState(path string) *downloadState {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var state downloadState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil
+	}
+	return &state
+}
+
+func (state *downloadState) save(path string) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// If-Range使用的校验值, 弱ETag不能用于If-Range, 此时使用Last-Modified
+func (state *downloadState) validator() string {
+	if state.ETag != "" && !strings.HasPrefix(state.ETag, "W/") {
+		return state.ETag
+	}
+	return state.LastModified
+}
+
+// 是否与服务器上的文件一致, 用于判断能否继续使用已下载的分片
+func (state *downloadState) same(other *downloadState) bool {
+	return other != nil && state.Url == other.Url && state.Size == other.Size && state.ChunkSize == other.ChunkSize &&
+		state.ETag == other.ETag && state.LastModified == other.LastModified && state.validator() != ""
+}
+
+// 删除下载临时文件及断点续传状态
+func removeDownload(path string) {
+	os.Remove(path + ".part")
+	os.Remove(path + ".part.json")
+}
+
+// 解析Content-Range响应头, 如 "bytes 0-99/1000"、"bytes */1000", 范围或总长度未知时为-1
+func parseContentRange(value string) (start, end, size int64, err error) {
+	start, end, size = -1, -1, -1
+	invalid := fmt.Errorf("Content-Range \"%s\"格式错误", value)
+	if !strings.HasPrefix(value, "bytes ") {
+		return start, end, size, invalid
+	}
+	parts := strings.SplitN(strings.TrimPrefix(value, "bytes "), "/", 2)
+	if len(parts) != 2 {
+		return start, end, size, invalid
+	}
+	if parts[1] != "*" {
+		if size, err = strconv.ParseInt(parts[1], 10, 64); err != nil {
+			return -1, -1, -1, invalid
+		}
+	}
+	if parts[0] == "*" {
+		return start, end, size, nil
+	}
+	bounds := strings.SplitN(parts[0], "-", 2)
+	if len(bounds) != 2 {
+		return -1, -1, -1, invalid
+	}
+	if start, err = strconv.ParseInt(bounds[0], 10, 64); err != nil {
+		return -1, -1, -1, invalid
+	}
+	if end, err = strconv.ParseInt(bounds[1], 10, 64); err != nil || end < start {
+		return -1, -1, -1, invalid
+	}
+	return start, end, size, nil
+}
+
+// 从指定位置写入文件
+type offsetWriter struct {
+	file   *os.File
+	offset int64
+}
+
+func (w *offsetWriter) Write(p []byte) (int, error) {
+	n, err := w.file.WriteAt(p, w.offset)
+	w.offset += int64(n)
+	return n, err
+}
+
+// 复制请求, 用于并发发送同一请求, 复制后修改请求参数及请求头不影响原请求
+func (req *Request) clone() *Request {
+	r := *req
+	r.Params = copyMap(req.Params)
+	r.Headers = copyMap(req.Headers)
+	r.Cookies = copyMap(req.Cookies)
+	r.ParamValues = copyValues(req.ParamValues)
+	r.HeaderValues = req.HeaderValues.Clone()
+	return &r
+}
+
+func copyMap(origin map[string]string) map[string]string {
+	if origin == nil {
+		return nil
+	}
+	result := make(map[string]string, len(origin))
+	updateMap(result, origin)
+	return result
+}
+
+func copyValues(origin map[string][]string) map[string][]string {
+	if origin == nil {
+		return nil
+	}
+	result := make(map[string][]string, len(origin))
+	for key, values := range origin {
+		result[key] = append([]string(nil), values...)
+	}
+	return result
+}
+
+// 并发分片下载, 先通过HEAD请求确认服务器支持Range并获取文件长度及ETag
+func (req *Request) downloadParallel(ctx context.Context, path string, opts *DownloadOptions) (*Response, error) {
+	probe := req.clone()
+	probe.Method = http.MethodHead
+	probe.Stream = false
+	resp, err := probe.DoContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 || resp.Headers["Accept-Ranges"] != "bytes" || resp.ContentLength <= 0 {
+		// 服务器不支持HEAD(如仅对GET签名的预签名URL)、不支持Range或长度未知, 回退为单连接下载
+		return req.download(ctx, path, opts)
+	}
+
+	partPath, statePath := path+".part", path+".part.json"
+	state := newDownloadState(req.fullUrl(), resp, resp.ContentLength)
+	state.ChunkSize = opts.ChunkSize
+	if state.ChunkSize <= 0 {
+		state.ChunkSize = defaultChunkSize
+	}
+	done := map[int64]bool{}
+	if old := loadDownloadState(statePath); opts.Resume && state.same(old) {
+		if info, err := os.Stat(partPath); err == nil && info.Size() == state.Size {
+			for _, start := range old.Done {
+				done[start] = true
+				state.Done = append(state.Done, start)
+			}
+		}
+	}
+	file, err := os.OpenFile(partPath, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return resp, err
+	}
+	if len(done) == 0 {
+		err = file.Truncate(0)
+	}
+	if err == nil {
+		err = file.Truncate(state.Size)
+	}
+	if err == nil && opts.Resume {
+		err = state.save(statePath)
+	}
+	if err != nil {
+		file.Close()
+		removeDownload(path)
+		return resp, err
+	}
+
+	var chunks []int64
+	var transferred int64
+	for start := int64(0); start < state.Size; start += state.ChunkSize {
+		if done[start] {
+			transferred += chunkEnd(start, state) - start + 1
+			continue
+		}
+		chunks = append(chunks, start)
+	}
+	counter := newProgressCounter(Progress{Transferred: transferred, Total: state.Size}, opts.Progress)
+	queue := make(chan int64, len(chunks))
+	for _, start := range chunks {
+		queue <- start
+	}
+	close(queue)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var mu sync.Mutex
+	var firstErr error
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Parallel && i < len(chunks); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for start := range queue {
+				err := req.downloadChunk(ctx, file, start, chunkEnd(start, state), state, counter)
+				mu.Lock()
+				if err == nil && opts.Resume {
+					state.Done = append(state.Done, start)
+					sort.Slice(state.Done, func(i, j int) bool { return state.Done[i] < state.Done[j] })
+					if err = file.Sync(); err == nil {
+						err = state.save(statePath)
+					}
+				}
+				if err != nil && firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				mu.Unlock()
+				if err != nil {
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		file.Close()
+		if !opts.Resume || errors.Is(firstErr, ErrRangeMismatch) {
+			removeDownload(path)
+		}
+		return resp, firstErr
+	}
+	if err = finishPart(file, path, opts.Checksum); err != nil {
+		removeDownload(path)
+		return resp, err
+	}
+	os.Remove(statePath)
+	return resp, nil
+}
+
+// 分片结束位置
+func chunkEnd(start int64, state *downloadState) int64 {
+	end := start + state.ChunkSize - 1
+	if end >= state.Size {
+		end = state.Size - 1
+	}
+	return end
+}
+
+// 下载单个分片并写入文件对应位置, 校验Content-Range及ETag
+func (req *Request) downloadChunk(ctx context.Context, file *os.File, start, end int64, state *downloadState, counter *progressCounter) error {
+	chunk := req.clone()
+	chunk.Stream = true
+	chunk.SetHeaders(map[string]string{"Range": fmt.Sprintf("bytes=%d-%d", start, end)})
+	if validator := state.validator(); validator != "" {
+		chunk.Headers["If-Range"] = validator
+	}
+	resp, err := chunk.DoContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer resp.Close()
+	if resp.StatusCode != http.StatusPartialContent {
+		return chunk.newError(ErrReadBody, fmt.Errorf("%w: 状态码 %d", ErrRangeMismatch, resp.StatusCode))
+	}
+	first, last, size, err := parseContentRange(resp.Headers["Content-Range"])
+	if err != nil || first != start || last != end || size != state.Size {
+		return chunk.newError(ErrReadBody, fmt.Errorf("%w: Content-Range \"%s\"", ErrRangeMismatch, resp.Headers["Content-Range"]))
+	}
+	if state.ETag != "" && resp.Headers["Etag"] != state.ETag {
+		return chunk.newError(ErrReadBody, fmt.Errorf("%w: ETag由%s变为%s", ErrRangeMismatch, state.ETag, resp.Headers["Etag"]))
+	}
+	writer := &progressWriter{writer: &offsetWriter{file: file, offset: start}, counter: counter}
+	n, err := io.Copy(writer, resp.Body)
+	if err == nil && n != end-start+1 {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return chunk.newError(ErrReadBody, err)
+	}
+	return nil
+}
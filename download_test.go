@@ -0,0 +1,325 @@
+package go_requests
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newDownloadServer(content string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		_, _ = w.Write([]byte(content))
+	}))
+}
+
+// 测试流式响应不读取响应数据
+func TestResponseStream(t *testing.T) {
+	server := newDownloadServer("hello world")
+	defer server.Close()
+
+	resp, err := NewRequest("GET", server.URL).SetStream(true).Do()
+	if err != nil {
+		t.Fatalf("请求失败: %s", err)
+	}
+	defer resp.Close()
+	if resp.Body == nil || resp.Content != nil || resp.Text != "" {
+		t.Fatal("流式响应不应读取响应数据")
+	}
+	if resp.ContentLength != 11 {
+		t.Errorf("期望ContentLength为11, 实际 %d", resp.ContentLength)
+	}
+	data, _ := ioutil.ReadAll(resp.Body)
+	if string(data) != "hello world" {
+		t.Errorf("期望 hello world, 实际 %s", data)
+	}
+}
+
+// 测试下载到文件、进度回调及校验值
+func TestRequestDownload(t *testing.T) {
+	content := strings.Repeat("go_requests", 10000)
+	server := newDownloadServer(content)
+	defer server.Close()
+	dir, _ := ioutil.TempDir("", "download")
+	defer os.RemoveAll(dir)
+
+	sum := sha256.Sum256([]byte(content))
+	path := filepath.Join(dir, "file.bin")
+	var last Progress
+	opts := &DownloadOptions{
+		Progress: func(progress Progress) { last = progress },
+		Checksum: "sha256:" + hex.EncodeToString(sum[:]),
+	}
+	if _, err := NewRequest("GET", server.URL).Download(path, opts); err != nil {
+		t.Fatalf("下载失败: %s", err)
+	}
+	data, _ := ioutil.ReadFile(path)
+	if string(data) != content {
+		t.Error("下载文件内容错误")
+	}
+	if last.Transferred != int64(len(content)) || last.Total != int64(len(content)) {
+		t.Errorf("进度错误: %+v", last)
+	}
+	if _, err := os.Stat(path + ".part"); !os.IsNotExist(err) {
+		t.Error("下载完成后不应保留临时文件")
+	}
+
+	// 校验值不匹配时不生成目标文件
+	badPath := filepath.Join(dir, "bad.bin")
+	_, err := NewRequest("GET", server.URL).Download(badPath, &DownloadOptions{Checksum: "md5:0000"})
+	if !errors.Is(err, ErrChecksum) {
+		t.Fatalf("期望校验值错误, 实际 %v", err)
+	}
+	if _, err := os.Stat(badPath); !os.IsNotExist(err) {
+		t.Error("校验失败时不应生成目标文件")
+	}
+	if _, err := os.Stat(badPath + ".part"); !os.IsNotExist(err) {
+		t.Error("校验失败时应删除临时文件")
+	}
+
+	// 非2xx响应不保存文件
+	missingPath := filepath.Join(dir, "missing.bin")
+	if _, err := NewRequest("GET", server.URL+"/missing").Download(missingPath, nil); err == nil {
+		t.Fatal("期望404错误")
+	}
+	if _, err := os.Stat(missingPath); !os.IsNotExist(err) {
+		t.Error("请求失败时不应生成目标文件")
+	}
+}
+
+// 测试保存非流式响应
+func TestResponseSaveTo(t *testing.T) {
+	server := newDownloadServer("hello")
+	defer server.Close()
+	dir, _ := ioutil.TempDir("", "download")
+	defer os.RemoveAll(dir)
+
+	resp, err := NewRequest("GET", server.URL).Do()
+	if err != nil {
+		t.Fatalf("请求失败: %s", err)
+	}
+	path := filepath.Join(dir, "hello.txt")
+	if err := resp.SaveTo(path, &DownloadOptions{Checksum: "unknown:1"}); err == nil {
+		t.Error("期望不支持的校验算法错误")
+	}
+	if err := resp.SaveTo(path, nil); err != nil {
+		t.Fatalf("保存失败: %s", err)
+	}
+	data, _ := ioutil.ReadFile(path)
+	if string(data) != "hello" {
+		t.Errorf("期望 hello, 实际 %s", data)
+	}
+}
+
+// 支持Range的下载服务, 通过etag控制文件ETag, 记录收到的Range请求头
+func newRangeServer(content string, etag *string, ranges *[]string) *httptest.Server {
+	var mu sync.Mutex
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		*ranges = append(*ranges, r.Header.Get("Range"))
+		w.Header().Set("ETag", *etag)
+		mu.Unlock()
+		http.ServeContent(w, r, "file.bin", time.Time{}, strings.NewReader(content))
+	}))
+}
+
+// 测试并发分片下载
+func TestRequestDownloadParallel(t *testing.T) {
+	content := strings.Repeat("0123456789", 10000)
+	etag := `"v1"`
+	var ranges []string
+	server := newRangeServer(content, &etag, &ranges)
+	defer server.Close()
+	dir, _ := ioutil.TempDir("", "download")
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "file.bin")
+	var last Progress
+	var mu sync.Mutex
+	opts := &DownloadOptions{Parallel: 4, ChunkSize: 30000, Progress: func(progress Progress) {
+		mu.Lock()
+		last = progress
+		mu.Unlock()
+	}}
+	if _, err := NewRequest("GET", server.URL).Download(path, opts); err != nil {
+		t.Fatalf("下载失败: %s", err)
+	}
+	data, _ := ioutil.ReadFile(path)
+	if string(data) != content {
+		t.Error("下载文件内容错误")
+	}
+	if len(ranges) != 5 { // 1次HEAD探测及4个分片
+		t.Errorf("期望5次请求, 实际 %d: %v", len(ranges), ranges)
+	}
+	if last.Transferred != int64(len(content)) || last.Total != int64(len(content)) {
+		t.Errorf("进度错误: %+v", last)
+	}
+}
+
+// 测试服务器不支持Range时回退为单连接下载
+func TestRequestDownloadParallelFallback(t *testing.T) {
+	server := newDownloadServer("hello world")
+	defer server.Close()
+	dir, _ := ioutil.TempDir("", "download")
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "file.bin")
+	if _, err := NewRequest("GET", server.URL).Download(path, &DownloadOptions{Parallel: 4}); err != nil {
+		t.Fatalf("下载失败: %s", err)
+	}
+	if data, _ := ioutil.ReadFile(path); string(data) != "hello world" {
+		t.Errorf("期望 hello world, 实际 %s", data)
+	}
+
+	// 服务器拒绝HEAD请求时(如预签名URL)回退为单连接下载
+	rejectHead := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Accept-Ranges", "bytes")
+		_, _ = w.Write([]byte("presigned"))
+	}))
+	defer rejectHead.Close()
+	path = filepath.Join(dir, "presigned.bin")
+	if _, err := NewRequest("GET", rejectHead.URL).Download(path, &DownloadOptions{Parallel: 4}); err != nil {
+		t.Fatalf("下载失败: %s", err)
+	}
+	if data, _ := ioutil.ReadFile(path); string(data) != "presigned" {
+		t.Errorf("期望 presigned, 实际 %s", data)
+	}
+}
+
+// 测试分片下载过程中文件变化
+func TestRequestDownloadParallelChanged(t *testing.T) {
+	content := strings.Repeat("0123456789", 1000)
+	var mu sync.Mutex
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		w.Header().Set("ETag", fmt.Sprintf(`"v%d"`, requests)) // 每次请求ETag都不同
+		mu.Unlock()
+		http.ServeContent(w, r, "file.bin", time.Time{}, strings.NewReader(content))
+	}))
+	defer server.Close()
+	dir, _ := ioutil.TempDir("", "download")
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "file.bin")
+	_, err := NewRequest("GET", server.URL).Download(path, &DownloadOptions{Parallel: 2, ChunkSize: 5000, Resume: true})
+	if !errors.Is(err, ErrRangeMismatch) {
+		t.Fatalf("期望ErrRangeMismatch, 实际 %v", err)
+	}
+	for _, name := range []string{path, path + ".part", path + ".part.json"} {
+		if _, err := os.Stat(name); !os.IsNotExist(err) {
+			t.Errorf("文件变化时不应保留 %s", name)
+		}
+	}
+}
+
+// 测试下载中断后断点续传, 包括使用Config中BaseUrl的相对地址
+func TestRequestDownloadResume(t *testing.T) {
+	t.Run("Url", func(t *testing.T) {
+		testDownloadResume(t, func(server *httptest.Server) *Request {
+			return NewRequest("GET", server.URL)
+		})
+	})
+	t.Run("BaseUrl", func(t *testing.T) {
+		testDownloadResume(t, func(server *httptest.Server) *Request {
+			return NewRequestWithConfig(NewConfig().SetBaseUrl(server.URL), "GET", "/file.bin")
+		})
+	})
+}
+
+func testDownloadResume(t *testing.T, newRequest func(server *httptest.Server) *Request) {
+	content := strings.Repeat("0123456789", 10000)
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		w.Header().Set("ETag", `"v1"`)
+		if len(ranges) == 1 { // 第一次请求只返回一半数据后断开连接
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			_, _ = w.Write([]byte(content[:len(content)/2]))
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		http.ServeContent(w, r, "file.bin", time.Time{}, strings.NewReader(content))
+	}))
+	defer server.Close()
+	dir, _ := ioutil.TempDir("", "download")
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "file.bin")
+	opts := &DownloadOptions{Resume: true}
+	if _, err := newRequest(server).Download(path, opts); !errors.Is(err, ErrReadBody) {
+		t.Fatalf("期望读取响应错误, 实际 %v", err)
+	}
+	if info, err := os.Stat(path + ".part"); err != nil || info.Size() != int64(len(content)/2) {
+		t.Fatal("下载中断时应保留已下载部分")
+	}
+
+	var first Progress
+	opts.Progress = func(progress Progress) {
+		if first.Total == 0 {
+			first = progress
+		}
+	}
+	req := newRequest(server)
+	resp, err := req.Download(path, opts)
+	if err != nil {
+		t.Fatalf("续传失败: %s", err)
+	}
+	if req.Stream || req.Headers["Range"] != "" || req.Headers["If-Range"] != "" {
+		t.Errorf("下载不应修改原请求: %v %v", req.Stream, req.Headers)
+	}
+	if resp.StatusCode != http.StatusPartialContent || ranges[1] != fmt.Sprintf("bytes=%d-", len(content)/2) {
+		t.Errorf("期望Range续传, 实际状态码 %d, Range %s", resp.StatusCode, ranges[1])
+	}
+	if first.Total != int64(len(content)) || first.Transferred <= int64(len(content)/2) {
+		t.Errorf("续传进度错误: %+v", first)
+	}
+	if data, _ := ioutil.ReadFile(path); string(data) != content {
+		t.Error("续传后文件内容错误")
+	}
+	if _, err := os.Stat(path + ".part.json"); !os.IsNotExist(err) {
+		t.Error("下载完成后应删除续传状态文件")
+	}
+}
+
+// 测试解析Content-Range
+func TestParseContentRange(t *testing.T) {
+	cases := []struct {
+		value            string
+		start, end, size int64
+		ok               bool
+	}{
+		{"bytes 0-99/1000", 0, 99, 1000, true},
+		{"bytes 100-199/*", 100, 199, -1, true},
+		{"bytes */1000", -1, -1, 1000, true},
+		{"bytes 200-100/1000", -1, -1, -1, false},
+		{"items 0-1/2", -1, -1, -1, false},
+	}
+	for _, c := range cases {
+		start, end, size, err := parseContentRange(c.value)
+		if (err == nil) != c.ok || start != c.start || end != c.end || size != c.size {
+			t.Errorf("%s: 解析结果错误 %d %d %d %v", c.value, start, end, size, err)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package go_requests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// 错误类型, 可通过 errors.Is(err, ErrTimeout) 判断
+var (
+	ErrInvalidUrl = errors.New("URL无效")
+	ErrBuildBody  = errors.New("构造请求数据失败")
+	ErrTransport  = errors.New("发送请求失败")
+	ErrTimeout    = errors.New("请求超时")
+	ErrReadBody   = errors.New("读取响应数据失败")
+	ErrAuth       = errors.New("获取认证信息失败")
+)
+
+// Error 请求错误, 可通过 errors.As 获取
+type Error struct {
+	Kind   error  // 错误类型, 为以上 Err* 之一
+	Method string // 请求方法
+	Url    string // 请求url
+	Err    error  // 原始错误
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s %s %s: %s", e.Method, e.Url, e.Kind, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) Is(target error) bool {
+	return e.Kind == target
+}
+
+// 判断是否为超时错误
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// 构造请求错误, 发送及读取响应时的超时统一归为 ErrTimeout
+func (req *Request) newError(kind error, err error) *Error {
+	if (kind == ErrTransport || kind == ErrReadBody) && isTimeout(err) {
+		kind = ErrTimeout
+	}
+	return &Error{Kind: kind, Method: strings.ToUpper(req.Method), Url: req.Url, Err: err}
+}
@@ -0,0 +1,90 @@
+package go_requests
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// 测试Do返回响应
+func TestDo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte("missing"))
+	}))
+	defer server.Close()
+
+	resp, err := NewRequest("GET", server.URL).Do()
+	if err != nil {
+		t.Fatalf("请求失败: %s", err)
+	}
+	if resp.StatusCode != 404 || resp.Reason != "Not Found" || resp.Text != "missing" {
+		t.Fatalf("响应不正确: %d %s %s", resp.StatusCode, resp.Reason, resp.Text)
+	}
+	if resp.Request == nil {
+		t.Fatal("响应未关联原始请求")
+	}
+}
+
+// 测试各类错误类型
+func TestDoErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	cases := []struct {
+		name string
+		req  *Request
+		kind error
+	}{
+		{"URL无效", NewRequest("GET", "://bad"), ErrInvalidUrl},
+		{"缺少主机", NewRequest("GET", "/get"), ErrInvalidUrl},
+		{"上传文件不存在", NewRequest("POST", server.URL).SetUploadFiles(map[string]string{"pic": "./testdata/missing.png"}), ErrBuildBody},
+		{"连接失败", NewRequest("GET", closed.URL), ErrTransport},
+		{"超时", NewRequest("GET", server.URL).SetTimeout(50), ErrTimeout},
+	}
+	for _, c := range cases {
+		resp, err := c.req.Do()
+		if !errors.Is(err, c.kind) {
+			t.Errorf("%s: 期望错误 %s, 实际 %v", c.name, c.kind, err)
+		}
+		var reqErr *Error
+		if !errors.As(err, &reqErr) || resp != nil {
+			t.Errorf("%s: 错误类型不正确 %v", c.name, err)
+		}
+	}
+}
+
+// 测试Send出错时不panic
+func TestSendError(t *testing.T) {
+	resp := NewRequest("GET", "/get").Send()
+	if resp == nil || resp.StatusCode != 0 {
+		t.Fatal("出错时应返回空响应")
+	}
+}
+
+// 测试创建Client失败时关闭请求数据, 不遗留multipart管道协程
+func TestDoClientErrorClosesBody(t *testing.T) {
+	before := runtime.NumGoroutine()
+	for i := 0; i < 20; i++ {
+		req := NewRequest("POST", "http://127.0.0.1:1/").
+			SetUploadFiles(map[string]string{"pic": "./testdata/logo.png"}).
+			SetLocalAddr("invalid")
+		if _, err := req.Do(); !errors.Is(err, ErrTransport) {
+			t.Fatalf("期望错误 %s, 实际 %v", ErrTransport, err)
+		}
+	}
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before+5 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before+5 {
+		t.Errorf("请求数据未关闭, 协程数 %d -> %d", before, n)
+	}
+}
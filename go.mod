@@ -2,11 +2,17 @@ module github.com/hanzhichao/go_requests
 
 go 1.17
 
-require golang.org/x/net v0.0.0-20220531201128-c960675eff93
+require (
+	github.com/andybalholm/brotli v1.0.4
+	github.com/klauspost/compress v1.15.9
+	github.com/tidwall/gjson v1.17.3
+	golang.org/x/crypto v0.0.0-20220525230936-793ad666bf5e
+	golang.org/x/net v0.0.0-20220531201128-c960675eff93
+	golang.org/x/text v0.3.7
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
-	github.com/tidwall/gjson v1.17.3 // indirect
 	github.com/tidwall/match v1.1.1 // indirect
 	github.com/tidwall/pretty v1.2.1 // indirect
-	golang.org/x/text v0.3.7 // indirect
 )
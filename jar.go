@@ -0,0 +1,279 @@
+package go_requests
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/net/publicsuffix"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cookie Cookie存储中的一项, 包含RFC 6265所需的全部属性
+type Cookie struct {
+	Name       string    `json:"name"`
+	Value      string    `json:"value"`
+	Domain     string    `json:"domain"`      // 所属域名, 不含前导点
+	Path       string    `json:"path"`        // 所属路径
+	Expires    time.Time `json:"expires"`     // 过期时间, 零值表示会话Cookie
+	Secure     bool      `json:"secure"`      // 仅通过HTTPS发送
+	HttpOnly   bool      `json:"http_only"`   // 仅用于HTTP请求
+	HostOnly   bool      `json:"host_only"`   // 仅发送给Domain本身, 不发送给子域名
+	Creation   time.Time `json:"creation"`    // 创建时间, 用于同路径长度Cookie排序
+	LastAccess time.Time `json:"last_access"` // 最后使用时间
+
+	seqNum uint64 // 存入顺序, 创建时间相同时用于排序
+}
+
+// 是否已过期
+func (c *Cookie) expired(now time.Time) bool {
+	return !c.Expires.IsZero() && !c.Expires.After(now)
+}
+
+// 是否应发送给指定主机及路径
+func (c *Cookie) match(host, path string) bool {
+	if c.Domain != host && (c.HostOnly || !hasDotSuffix(host, c.Domain)) {
+		return false
+	}
+	if path == c.Path {
+		return true
+	}
+	return strings.HasPrefix(path, c.Path) && (strings.HasSuffix(c.Path, "/") || path[len(c.Path)] == '/')
+}
+
+// CookieJar 遵循RFC 6265的Cookie存储, 使用公共后缀列表拒绝为顶级域名设置Cookie, 可并发使用
+type CookieJar struct {
+	mu         sync.Mutex
+	entries    map[string]*Cookie // 键为 域名;路径;名称
+	nextSeqNum uint64             // 下一个存入的Cookie的序号
+}
+
+func NewCookieJar() *CookieJar {
+	return &CookieJar{entries: map[string]*Cookie{}}
+}
+
+func cookieId(c *Cookie) string {
+	return fmt.Sprintf("%s;%s;%s", c.Domain, c.Path, c.Name)
+}
+
+func hasDotSuffix(s, suffix string) bool {
+	return len(s) > len(suffix) && s[len(s)-len(suffix)-1] == '.' && s[len(s)-len(suffix):] == suffix
+}
+
+// 获取URL中规范化的主机名, 不含端口
+func canonicalHost(u *url.URL) string {
+	return strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+}
+
+// 默认路径, 取URL路径最后一个斜杠之前的部分
+func defaultPath(path string) string {
+	if len(path) == 0 || path[0] != '/' {
+		return "/"
+	}
+	i := strings.LastIndex(path, "/")
+	if i == 0 {
+		return "/"
+	}
+	return path[:i]
+}
+
+// 计算Cookie的域名及是否HostOnly
+func cookieDomain(host, domain string) (string, bool, error) {
+	if domain == "" {
+		return host, true, nil
+	}
+	if net.ParseIP(host) != nil {
+		if domain != host {
+			return "", false, errors.New("IP地址不能设置Domain属性")
+		}
+		return host, true, nil
+	}
+	domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+	if domain == "" || strings.HasSuffix(domain, ".") {
+		return "", false, errors.New("Domain属性无效")
+	}
+	// 拒绝为公共后缀(如com、com.cn)设置Cookie
+	if ps, _ := publicsuffix.PublicSuffix(domain); ps != "" && !hasDotSuffix(domain, ps) {
+		if host == domain {
+			return host, true, nil
+		}
+		return "", false, errors.New("不能为公共后缀设置Cookie")
+	}
+	if host != domain && !hasDotSuffix(host, domain) {
+		return "", false, errors.New("Domain属性与主机不匹配")
+	}
+	return domain, false, nil
+}
+
+// SetCookies 保存响应中的Cookies, 实现 http.CookieJar
+func (jar *CookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return
+	}
+	host := canonicalHost(u)
+	if host == "" {
+		return
+	}
+	now := time.Now()
+	jar.mu.Lock()
+	defer jar.mu.Unlock()
+	if jar.entries == nil {
+		jar.entries = map[string]*Cookie{}
+	}
+	for _, cookie := range cookies {
+		domain, hostOnly, err := cookieDomain(host, cookie.Domain)
+		if err != nil {
+			continue
+		}
+		entry := &Cookie{
+			Name:       cookie.Name,
+			Value:      cookie.Value,
+			Domain:     domain,
+			Path:       cookie.Path,
+			Secure:     cookie.Secure,
+			HttpOnly:   cookie.HttpOnly,
+			HostOnly:   hostOnly,
+			Creation:   now,
+			LastAccess: now,
+		}
+		if entry.Path == "" || entry.Path[0] != '/' {
+			entry.Path = defaultPath(u.Path)
+		}
+		id := cookieId(entry)
+		switch {
+		case cookie.MaxAge < 0:
+			delete(jar.entries, id)
+			continue
+		case cookie.MaxAge > 0:
+			entry.Expires = now.Add(time.Duration(cookie.MaxAge) * time.Second)
+		case !cookie.Expires.IsZero():
+			if !cookie.Expires.After(now) {
+				delete(jar.entries, id)
+				continue
+			}
+			entry.Expires = cookie.Expires
+		}
+		if old, ok := jar.entries[id]; ok {
+			entry.Creation = old.Creation
+			entry.seqNum = old.seqNum
+		} else {
+			entry.seqNum = jar.nextSeqNum
+			jar.nextSeqNum++
+		}
+		jar.entries[id] = entry
+	}
+}
+
+// Cookies 获取发送到URL的Cookies, 按路径长度、创建时间及存入顺序排序, 实现 http.CookieJar
+func (jar *CookieJar) Cookies(u *url.URL) []*http.Cookie {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil
+	}
+	host := canonicalHost(u)
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+	now := time.Now()
+	jar.mu.Lock()
+	defer jar.mu.Unlock()
+	var selected []*Cookie
+	for id, entry := range jar.entries {
+		if entry.expired(now) {
+			delete(jar.entries, id)
+			continue
+		}
+		if (entry.Secure && u.Scheme != "https") || !entry.match(host, path) {
+			continue
+		}
+		entry.LastAccess = now
+		selected = append(selected, entry)
+	}
+	sort.Slice(selected, func(i, j int) bool {
+		if len(selected[i].Path) != len(selected[j].Path) {
+			return len(selected[i].Path) > len(selected[j].Path)
+		}
+		if !selected[i].Creation.Equal(selected[j].Creation) {
+			return selected[i].Creation.Before(selected[j].Creation)
+		}
+		return selected[i].seqNum < selected[j].seqNum
+	})
+	cookies := make([]*http.Cookie, 0, len(selected))
+	for _, entry := range selected {
+		cookies = append(cookies, &http.Cookie{Name: entry.Name, Value: entry.Value})
+	}
+	return cookies
+}
+
+// All 获取全部未过期的Cookie
+func (jar *CookieJar) All() []*Cookie {
+	now := time.Now()
+	jar.mu.Lock()
+	defer jar.mu.Unlock()
+	cookies := make([]*Cookie, 0, len(jar.entries))
+	for id, entry := range jar.entries {
+		if entry.expired(now) {
+			delete(jar.entries, id)
+			continue
+		}
+		cookie := *entry
+		cookies = append(cookies, &cookie)
+	}
+	sort.Slice(cookies, func(i, j int) bool {
+		return cookieId(cookies[i]) < cookieId(cookies[j])
+	})
+	return cookies
+}
+
+// Add 直接添加Cookie, 用于恢复保存的Cookie, 已过期的Cookie会被忽略
+func (jar *CookieJar) Add(cookies ...*Cookie) {
+	now := time.Now()
+	jar.mu.Lock()
+	defer jar.mu.Unlock()
+	if jar.entries == nil {
+		jar.entries = map[string]*Cookie{}
+	}
+	for _, cookie := range cookies {
+		if cookie.expired(now) {
+			continue
+		}
+		entry := *cookie
+		entry.Domain = strings.ToLower(strings.TrimPrefix(entry.Domain, "."))
+		if entry.Path == "" {
+			entry.Path = "/"
+		}
+		if entry.Creation.IsZero() {
+			entry.Creation = now
+		}
+		entry.seqNum = jar.nextSeqNum
+		jar.nextSeqNum++
+		jar.entries[cookieId(&entry)] = &entry
+	}
+}
+
+// ClearURL 删除会发送到URL的全部Cookie(含Secure Cookie)
+func (jar *CookieJar) ClearURL(u *url.URL) {
+	host := canonicalHost(u)
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+	jar.mu.Lock()
+	defer jar.mu.Unlock()
+	for id, entry := range jar.entries {
+		if entry.match(host, path) {
+			delete(jar.entries, id)
+		}
+	}
+}
+
+// Clear 删除全部Cookie
+func (jar *CookieJar) Clear() {
+	jar.mu.Lock()
+	defer jar.mu.Unlock()
+	jar.entries = map[string]*Cookie{}
+}
@@ -0,0 +1,62 @@
+package go_requests
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func cookieNames(cookies []*http.Cookie) string {
+	names := ""
+	for _, cookie := range cookies {
+		names += cookie.Name + ";"
+	}
+	return names
+}
+
+// 测试Cookie的域名、路径、Secure及过期规则
+func TestCookieJar(t *testing.T) {
+	jar := NewCookieJar()
+	setUrl, _ := url.Parse("https://www.example.com/account/login")
+	jar.SetCookies(setUrl, []*http.Cookie{
+		{Name: "host", Value: "1"},
+		{Name: "domain", Value: "1", Domain: ".example.com", Path: "/"},
+		{Name: "secure", Value: "1", Path: "/", Secure: true},
+		{Name: "expired", Value: "1", Expires: time.Now().Add(-time.Hour)},
+		{Name: "deep", Value: "1", Path: "/account/settings"},
+		{Name: "suffix", Value: "1", Domain: "com"},
+		{Name: "other", Value: "1", Domain: "other.com"},
+	})
+
+	cases := map[string]string{
+		"https://www.example.com/account/settings/profile": "deep;host;domain;secure;",
+		"https://www.example.com/account":                  "host;domain;secure;",
+		"http://www.example.com/account":                   "host;domain;",
+		"https://api.example.com/account":                  "domain;",
+		"https://example.com/":                             "domain;",
+		"https://example.org/":                             "",
+	}
+	for rawUrl, expected := range cases {
+		u, _ := url.Parse(rawUrl)
+		if names := cookieNames(jar.Cookies(u)); names != expected {
+			t.Errorf("%s: 期望 %s, 实际 %s", rawUrl, expected, names)
+		}
+	}
+
+	// 使用Max-Age删除Cookie
+	jar.SetCookies(setUrl, []*http.Cookie{{Name: "host", MaxAge: -1}})
+	u, _ := url.Parse("https://www.example.com/account")
+	if names := cookieNames(jar.Cookies(u)); names != "domain;secure;" {
+		t.Errorf("删除Cookie失败: %s", names)
+	}
+
+	jar.ClearURL(u)
+	if len(jar.All()) != 1 {
+		t.Errorf("按URL删除Cookie失败: %d", len(jar.All()))
+	}
+	jar.Clear()
+	if len(jar.All()) != 0 {
+		t.Error("清空Cookie失败")
+	}
+}
@@ -0,0 +1,74 @@
+package go_requests
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// JsonOptions JSON请求数据序列化配置
+type JsonOptions struct {
+	EscapeHTML bool                                `json:"escape_html"` // 是否转义<、>、&等HTML字符，默认不转义
+	Indent     string                              `json:"indent"`      // 缩进字符串，如"  "，默认不缩进
+	Marshal    func(v interface{}) ([]byte, error) `json:"-"`           // 自定义序列化函数，设置后忽略EscapeHTML及Indent
+}
+
+// 按配置序列化JSON
+func (opts *JsonOptions) marshal(v interface{}) ([]byte, error) {
+	if opts == nil {
+		opts = &JsonOptions{}
+	}
+	if opts.Marshal != nil {
+		return opts.Marshal(v)
+	}
+	buffer := &bytes.Buffer{}
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(opts.EscapeHTML)
+	encoder.SetIndent("", opts.Indent)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buffer.Bytes(), "\n"), nil
+}
+
+// SetJson 设置JSON请求数据，发送时按JsonOptions序列化结构体、map或切片，优先于Json字符串
+func (req *Request) SetJson(v interface{}) *Request {
+	req.JsonValue = v
+	return req
+}
+
+func (req *Request) SetJsonOptions(opts *JsonOptions) *Request {
+	req.JsonOptions = opts
+	return req
+}
+
+// 组装JSON请求数据
+func (req *Request) jsonBody() (string, error) {
+	if req.JsonValue == nil {
+		return req.Json, nil
+	}
+	data, err := req.JsonOptions.marshal(req.JsonValue)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// JSON请求文件中的json字段，可以是JSON字符串或任意JSON值
+type jsonField string
+
+func (field *jsonField) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*field = jsonField(text)
+		return nil
+	}
+	if string(data) == "null" {
+		return nil
+	}
+	buffer := &bytes.Buffer{}
+	if err := json.Compact(buffer, data); err != nil {
+		return err
+	}
+	*field = jsonField(buffer.String())
+	return nil
+}
@@ -0,0 +1,65 @@
+package go_requests
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// 测试序列化结构体、map及切片为JSON请求数据
+func TestRequestSetJson(t *testing.T) {
+	server := newEchoServer()
+	defer server.Close()
+
+	type item struct {
+		Name string `json:"name"`
+		Html string `json:"html"`
+	}
+	cases := []struct {
+		name     string
+		req      *Request
+		expected string
+	}{
+		{"结构体", NewRequest("POST", server.URL).SetJson(item{"张三", "<b>&</b>"}),
+			`{"name":"张三","html":"<b>&</b>"}`},
+		{"切片", NewRequest("POST", server.URL).SetJson([]int{1, 2}), `[1,2]`},
+		{"转义HTML及缩进", NewRequest("POST", server.URL).SetJson(map[string]string{"html": "<b>"}).
+			SetJsonOptions(&JsonOptions{EscapeHTML: true, Indent: "  "}),
+			"{\n  \"html\": \"\\u003cb\\u003e\"\n}"},
+		{"自定义序列化函数", NewRequestWithConfig(NewConfig().SetJsonOptions(&JsonOptions{Marshal: func(v interface{}) ([]byte, error) {
+			return []byte(`{"custom":true}`), nil
+		}}), "POST", server.URL).SetJson(item{}), `{"custom":true}`},
+	}
+	for _, c := range cases {
+		resp, err := c.req.Do()
+		if err != nil {
+			t.Fatalf("%s: 请求失败: %s", c.name, err)
+		}
+		if resp.Text != c.expected || resp.Headers["Content-Type"] != "application/json" {
+			t.Errorf("%s: 期望 %s, 实际 %s(%s)", c.name, c.expected, resp.Text, resp.Headers["Content-Type"])
+		}
+	}
+
+	if _, err := NewRequest("POST", server.URL).SetJson(map[string]interface{}{"f": func() {}}).Do(); err == nil {
+		t.Error("期望序列化错误")
+	}
+}
+
+// 测试JSON请求文件中json字段为字符串或任意JSON值
+func TestRequestJsonField(t *testing.T) {
+	cases := map[string]string{
+		`{"json": "{\"a\": 1}"}`:       `{"a": 1}`,
+		`{"json": {"a": 1, "b": [1]}}`: `{"a":1,"b":[1]}`,
+		`{"json": [1, 2]}`:             `[1,2]`,
+		`{"json": 12}`:                 `12`,
+		`{"json": null}`:               ``,
+	}
+	for data, expected := range cases {
+		var req Request
+		if err := json.Unmarshal([]byte(data), &req); err != nil {
+			t.Fatalf("%s: 解析失败: %s", data, err)
+		}
+		if req.Json != expected {
+			t.Errorf("%s: 期望 %s, 实际 %s", data, expected, req.Json)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package go_requests
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// FormPart multipart/form-data中的一个部分, 设置FilePath、Content、Reader或FileName时作为文件上传
+type FormPart struct {
+	Name        string            `json:"name"`         // 字段名, 可重复
+	Value       string            `json:"value"`        // 普通字段的值
+	FilePath    string            `json:"file_path"`    // 上传文件路径, 发送时流式读取
+	FileName    string            `json:"file_name"`    // 文件名, 默认取FilePath中的文件名
+	Content     []byte            `json:"content"`      // 内存中的文件内容
+	Reader      io.Reader         `json:"-"`            // 流式文件内容, 只能读取一次, 请求不可重试
+	ContentType string            `json:"content_type"` // 该部分的Content-Type, 文件默认 application/octet-stream
+	Headers     map[string]string `json:"headers"`      // 该部分的额外头信息
+}
+
+// AddPart 添加multipart/form-data部分, 按添加顺序发送
+func (req *Request) AddPart(part *FormPart) *Request {
+	req.Parts = append(req.Parts, part)
+	return req
+}
+
+// AddFieldPart 添加multipart/form-data普通字段
+func (req *Request) AddFieldPart(name, value string) *Request {
+	return req.AddPart(&FormPart{Name: name, Value: value})
+}
+
+// AddFilePart 添加上传文件, contentType为空时使用 application/octet-stream
+func (req *Request) AddFilePart(name, filePath, contentType string) *Request {
+	return req.AddPart(&FormPart{Name: name, FilePath: filePath, ContentType: contentType})
+}
+
+// AddBytesPart 添加内存中的文件内容
+func (req *Request) AddBytesPart(name, fileName string, content []byte, contentType string) *Request {
+	return req.AddPart(&FormPart{Name: name, FileName: fileName, Content: content, ContentType: contentType})
+}
+
+// AddReaderPart 添加流式文件内容, reader只能读取一次, 请求不可重试
+func (req *Request) AddReaderPart(name, fileName string, reader io.Reader, contentType string) *Request {
+	return req.AddPart(&FormPart{Name: name, FileName: fileName, Reader: reader, ContentType: contentType})
+}
+
+// 是否作为文件上传
+func (part *FormPart) isFile() bool {
+	return part.FilePath != "" || part.FileName != "" || part.Content != nil || part.Reader != nil
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// 该部分的头信息
+func (part *FormPart) header() textproto.MIMEHeader {
+	header := textproto.MIMEHeader{}
+	disposition := fmt.Sprintf(`form-data; name="%s"`, quoteEscaper.Replace(part.Name))
+	if part.isFile() {
+		fileName := part.FileName
+		if fileName == "" {
+			fileName = filepath.Base(part.FilePath)
+		}
+		disposition += fmt.Sprintf(`; filename="%s"`, quoteEscaper.Replace(fileName))
+		header.Set("Content-Type", "application/octet-stream")
+	}
+	header.Set("Content-Disposition", disposition)
+	if part.ContentType != "" {
+		header.Set("Content-Type", part.ContentType)
+	}
+	for key, value := range part.Headers {
+		header.Set(key, value)
+	}
+	return header
+}
+
+// 写入该部分的内容, measure为true时不写入文件内容, 返回未写入的长度, 用于计算请求数据总长度
+func (part *FormPart) write(writer io.Writer, measure bool) (int64, error) {
+	switch {
+	case part.FilePath != "":
+		if measure {
+			info, err := os.Stat(part.FilePath)
+			if err != nil {
+				return 0, err
+			}
+			return info.Size(), nil
+		}
+		file, err := os.Open(part.FilePath)
+		if err != nil {
+			return 0, err
+		}
+		defer file.Close()
+		_, err = io.Copy(writer, file)
+		return 0, err
+	case part.Reader != nil:
+		_, err := io.Copy(writer, part.Reader)
+		return 0, err
+	case part.Content != nil:
+		if measure {
+			return int64(len(part.Content)), nil
+		}
+		_, err := writer.Write(part.Content)
+		return 0, err
+	default:
+		_, err := io.WriteString(writer, part.Value)
+		return 0, err
+	}
+}
+
+// 合并Data、DataValues、Files及Parts, 普通字段在前, 文件在后
+func (req *Request) formParts() []*FormPart {
+	var parts []*FormPart
+	values := req.formValues()
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range values[key] {
+			parts = append(parts, &FormPart{Name: key, Value: value})
+		}
+	}
+	keys = keys[:0]
+	for key := range req.Files {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		parts = append(parts, &FormPart{Name: key, FilePath: req.Files[key]})
+	}
+	return append(parts, req.Parts...)
+}
+
+// 写入全部部分, 返回measure时未写入的文件总长度
+func writeParts(writer *multipart.Writer, parts []*FormPart, measure bool) (int64, error) {
+	var skipped int64
+	for _, part := range parts {
+		item, err := writer.CreatePart(part.header())
+		if err != nil {
+			return 0, err
+		}
+		n, err := part.write(item, measure)
+		if err != nil {
+			return 0, err
+		}
+		skipped += n
+	}
+	return skipped, writer.Close()
+}
+
+// 组装流式multipart/form-data请求数据, 通过管道边读取文件边发送, 不将文件读入内存
+func (req *Request) getMultipart() (*streamBody, error) {
+	parts := req.formParts()
+	replayable := true
+	for _, part := range parts {
+		if part.FilePath != "" {
+			if _, err := os.Stat(part.FilePath); err != nil {
+				return nil, fmt.Errorf("上传文件\"%s\"出错: %w", part.Name, err)
+			}
+		}
+		if part.Reader != nil {
+			replayable = false
+		}
+	}
+	boundary := multipart.NewWriter(ioutil.Discard).Boundary()
+	open := func() io.ReadCloser {
+		reader, pipeWriter := io.Pipe()
+		go func() {
+			writer := multipart.NewWriter(pipeWriter)
+			_ = writer.SetBoundary(boundary)
+			_, err := writeParts(writer, parts, false)
+			pipeWriter.CloseWithError(err)
+		}()
+		return reader
+	}
+	stream := &streamBody{length: -1}
+	if replayable {
+		// 不含io.Reader时可计算总长度, 避免使用chunked编码
+		measure := &bytes.Buffer{}
+		writer := multipart.NewWriter(measure)
+		_ = writer.SetBoundary(boundary)
+		size, err := writeParts(writer, parts, true)
+		if err != nil {
+			return nil, err
+		}
+		stream.length = int64(measure.Len()) + size
+		stream.getBody = func() (io.ReadCloser, error) {
+			return open(), nil
+		}
+	}
+	stream.body = open()
+	req.Headers["Content-Type"] = "multipart/form-data; boundary=" + boundary
+	return stream, nil
+}
@@ -0,0 +1,97 @@
+package go_requests
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// 返回各部分信息的服务, 每行格式为 字段名|文件名|Content-Type|X-Part|长度
+func newMultipartServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reader, err := r.MultipartReader()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("X-Content-Length", fmt.Sprint(r.ContentLength))
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			data, _ := ioutil.ReadAll(part)
+			_, _ = fmt.Fprintf(w, "%s|%s|%s|%s|%d\n", part.FormName(), part.FileName(),
+				part.Header.Get("Content-Type"), part.Header.Get("X-Part"), len(data))
+		}
+	}))
+}
+
+// 测试流式multipart请求, 包含重复字段、内存数据、文件及自定义头信息
+func TestMultipartParts(t *testing.T) {
+	server := newMultipartServer()
+	defer server.Close()
+	logo, _ := ioutil.ReadFile("./testdata/logo.png")
+
+	resp, err := NewRequest("POST", server.URL).
+		SetFormData(map[string]string{"name": "kevin"}).
+		AddFieldPart("tag", "a").
+		AddFieldPart("tag", "b").
+		AddFilePart("pic", "./testdata/logo.png", "image/png").
+		AddBytesPart("doc", "hello.txt", []byte("hello"), "text/plain").
+		AddPart(&FormPart{Name: "meta", Value: `{"a":1}`, ContentType: "application/json", Headers: map[string]string{"X-Part": "1"}}).
+		Do()
+	if err != nil {
+		t.Fatalf("请求失败: %s", err)
+	}
+	expected := strings.Join([]string{
+		"name||||5",
+		"tag||||1",
+		"tag||||1",
+		fmt.Sprintf("pic|logo.png|image/png||%d", len(logo)),
+		"doc|hello.txt|text/plain||5",
+		"meta||application/json|1|7",
+	}, "\n") + "\n"
+	if resp.Text != expected {
+		t.Errorf("期望\n%s实际\n%s", expected, resp.Text)
+	}
+	if resp.Headers["X-Content-Length"] == "-1" {
+		t.Error("不含io.Reader时应发送Content-Length")
+	}
+}
+
+// 测试io.Reader部分使用chunked编码发送
+func TestMultipartReaderPart(t *testing.T) {
+	server := newMultipartServer()
+	defer server.Close()
+
+	resp, err := NewRequest("POST", server.URL).
+		AddReaderPart("file", "data.bin", io.MultiReader(strings.NewReader("streaming")), "").
+		Do()
+	if err != nil {
+		t.Fatalf("请求失败: %s", err)
+	}
+	if resp.Text != "file|data.bin|application/octet-stream||9\n" {
+		t.Errorf("响应错误: %s", resp.Text)
+	}
+	if resp.Headers["X-Content-Length"] != "-1" {
+		t.Errorf("期望chunked编码, 实际Content-Length %s", resp.Headers["X-Content-Length"])
+	}
+}
+
+// 测试上传文件不存在
+func TestMultipartMissingFile(t *testing.T) {
+	_, err := NewRequest("POST", "http://127.0.0.1:1").AddFilePart("pic", "./testdata/missing.png", "").Do()
+	if !errors.Is(err, ErrBuildBody) || !strings.Contains(err.Error(), "pic") {
+		t.Fatalf("期望文件不存在错误, 实际 %v", err)
+	}
+}
@@ -0,0 +1,243 @@
+package go_requests
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OAuth2Token OAuth2访问令牌
+type OAuth2Token struct {
+	AccessToken  string    `json:"access_token" form:"access_token"`   // 访问令牌
+	TokenType    string    `json:"token_type" form:"token_type"`       // 令牌类型，一般为Bearer
+	RefreshToken string    `json:"refresh_token" form:"refresh_token"` // 刷新令牌
+	ExpiresIn    int64     `json:"expires_in" form:"expires_in"`       // 有效期，单位 秒
+	Scope        string    `json:"scope" form:"scope"`                 // 授权范围
+	Expiry       time.Time `json:"expiry" form:"-"`                    // 过期时间，为零值时不过期
+}
+
+// 令牌在delta时间后是否仍然有效
+func (t *OAuth2Token) valid(delta time.Duration) bool {
+	return t != nil && t.AccessToken != "" && (t.Expiry.IsZero() || time.Now().Add(delta).Before(t.Expiry))
+}
+
+// OAuth2Error 令牌接口返回的错误
+type OAuth2Error struct {
+	StatusCode  int    `json:"-" form:"-"`                                 // 响应状态码
+	Code        string `json:"error" form:"error"`                         // 错误码，如 invalid_client
+	Description string `json:"error_description" form:"error_description"` // 错误说明
+}
+
+func (e *OAuth2Error) Error() string {
+	return fmt.Sprintf("获取OAuth2令牌失败(状态码 %d): %s %s", e.StatusCode, e.Code, e.Description)
+}
+
+// OAuth2 OAuth2客户端认证，支持client_credentials、password及refresh_token授权方式
+// 自动获取并缓存令牌，令牌即将过期或请求返回401时刷新，并发请求只刷新一次
+type OAuth2 struct {
+	TokenURL     string            `json:"token_url"`     // 令牌接口地址
+	ClientID     string            `json:"client_id"`     // 客户端ID
+	ClientSecret string            `json:"client_secret"` // 客户端密钥
+	GrantType    string            `json:"grant_type"`    // 授权方式，client_credentials、password或refresh_token，默认client_credentials
+	Username     string            `json:"username"`      // password授权方式的用户名
+	Password     string            `json:"password"`      // password授权方式的密码
+	RefreshToken string            `json:"refresh_token"` // 初始刷新令牌，refresh_token授权方式使用
+	Scopes       []string          `json:"scopes"`        // 授权范围
+	Params       map[string]string `json:"params"`        // 令牌接口额外参数，如 audience
+	AuthInBody   bool              `json:"auth_in_body"`  // 客户端ID及密钥放在请求数据中，默认使用BasicAuth
+	ExpiryDelta  int               `json:"expiry_delta"`  // 提前刷新时间，单位 毫秒，默认10秒
+	Token        *OAuth2Token      `json:"token"`         // 当前令牌，可随Config保存
+	Config       *Config           `json:"-"`             // 令牌请求使用的Config，只使用其中的TLS、代理、拨号、超时及连接池配置
+
+	mu sync.Mutex
+}
+
+const defaultExpiryDelta = 10 * time.Second
+
+// NewOAuth2 使用client_credentials授权方式
+func NewOAuth2(tokenUrl, clientId, clientSecret string, scopes ...string) *OAuth2 {
+	return &OAuth2{TokenURL: tokenUrl, ClientID: clientId, ClientSecret: clientSecret, Scopes: scopes}
+}
+
+// SetPasswordGrant 使用password授权方式
+func (o *OAuth2) SetPasswordGrant(username, password string) *OAuth2 {
+	o.GrantType = "password"
+	o.Username = username
+	o.Password = password
+	return o
+}
+
+// SetRefreshToken 设置初始刷新令牌，未设置GrantType时使用refresh_token授权方式
+func (o *OAuth2) SetRefreshToken(refreshToken string) *OAuth2 {
+	if o.GrantType == "" {
+		o.GrantType = "refresh_token"
+	}
+	o.RefreshToken = refreshToken
+	return o
+}
+
+// MarshalJSON 在锁内序列化, 保存会话或Config时不与令牌刷新并发读写
+func (o *OAuth2) MarshalJSON() ([]byte, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	type oauth2 OAuth2
+	return json.Marshal((*oauth2)(o))
+}
+
+// SetConfig 设置令牌请求使用的Config, 令牌接口需要私有CA证书、代理等配置时使用
+func (o *OAuth2) SetConfig(config *Config) *OAuth2 {
+	o.Config = config
+	return o
+}
+
+// 令牌请求使用的配置, 不继承Config中的认证方式及默认请求头等, 避免获取令牌时递归认证
+func (o *OAuth2) tokenConfig() *Config {
+	conf := o.Config
+	if conf == nil {
+		return nil
+	}
+	return &Config{
+		Timeout:             conf.Timeout,
+		HTTP2:               conf.HTTP2,
+		Proxy:               conf.Proxy,
+		ProxyConfig:         conf.ProxyConfig,
+		TLS:                 conf.TLS,
+		UnixSocket:          conf.UnixSocket,
+		DialContext:         conf.DialContext,
+		LocalAddr:           conf.LocalAddr,
+		PreferIP:            conf.PreferIP,
+		MaxIdleConns:        conf.MaxIdleConns,
+		MaxIdleConnsPerHost: conf.MaxIdleConnsPerHost,
+		MaxConnsPerHost:     conf.MaxConnsPerHost,
+		IdleConnTimeout:     conf.IdleConnTimeout,
+		transports:          conf.pool(), // 与Config共享连接池
+	}
+}
+
+// GetToken 获取有效的访问令牌，令牌不存在或即将过期时请求令牌接口
+func (o *OAuth2) GetToken(ctx context.Context) (*OAuth2Token, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delta := defaultExpiryDelta
+	if o.ExpiryDelta > 0 {
+		delta = time.Duration(o.ExpiryDelta) * time.Millisecond
+	}
+	if o.Token.valid(delta) {
+		return o.Token, nil
+	}
+	token, err := o.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	o.Token = token
+	return token, nil
+}
+
+// 获取新令牌，有刷新令牌时优先刷新，刷新失败时按GrantType重新获取
+func (o *OAuth2) fetch(ctx context.Context) (*OAuth2Token, error) {
+	grantType := o.GrantType
+	if grantType == "" {
+		grantType = "client_credentials"
+	}
+	refreshToken := o.RefreshToken
+	if o.Token != nil && o.Token.RefreshToken != "" {
+		refreshToken = o.Token.RefreshToken
+	}
+	if refreshToken != "" {
+		token, err := o.requestToken(ctx, map[string]string{"grant_type": "refresh_token", "refresh_token": refreshToken})
+		if err == nil && token.RefreshToken == "" {
+			token.RefreshToken = refreshToken // 未返回新的刷新令牌时继续使用原刷新令牌
+		}
+		if err == nil || grantType == "refresh_token" {
+			return token, err
+		}
+	}
+	data := map[string]string{"grant_type": grantType}
+	switch grantType {
+	case "client_credentials":
+	case "password":
+		data["username"] = o.Username
+		data["password"] = o.Password
+	case "refresh_token":
+		return nil, fmt.Errorf("refresh_token授权方式缺少RefreshToken")
+	default:
+		return nil, fmt.Errorf("不支持的OAuth2授权方式 \"%s\"", grantType)
+	}
+	if len(o.Scopes) > 0 {
+		data["scope"] = strings.Join(o.Scopes, " ")
+	}
+	return o.requestToken(ctx, data)
+}
+
+// 请求令牌接口
+func (o *OAuth2) requestToken(ctx context.Context, data map[string]string) (*OAuth2Token, error) {
+	for key, value := range o.Params {
+		if _, ok := data[key]; !ok {
+			data[key] = value
+		}
+	}
+	req := NewRequestWithConfig(o.tokenConfig(), "POST", o.TokenURL).SetHeaders(map[string]string{"Accept": "application/json"})
+	if o.AuthInBody {
+		data["client_id"] = o.ClientID
+		if o.ClientSecret != "" {
+			data["client_secret"] = o.ClientSecret
+		}
+	} else if o.ClientID != "" {
+		// RFC 6749 2.3.1: 客户端ID及密钥需先进行表单编码
+		credentials := url.QueryEscape(o.ClientID) + ":" + url.QueryEscape(o.ClientSecret)
+		req.Headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials))
+	}
+	resp, err := req.SetFormData(data).DoContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		oauthErr := &OAuth2Error{StatusCode: resp.StatusCode}
+		_ = resp.Decode(oauthErr)
+		return nil, oauthErr
+	}
+	token := &OAuth2Token{}
+	if err := resp.Decode(token); err != nil {
+		return nil, err
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("令牌接口响应中缺少access_token")
+	}
+	if token.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+// Authorize 为请求添加Bearer令牌
+func (o *OAuth2) Authorize(r *http.Request) error {
+	token, err := o.GetToken(r.Context())
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	return nil
+}
+
+// Challenge 请求返回401时作废请求使用的令牌(保留刷新令牌), 重发时获取新令牌
+func (o *OAuth2) Challenge(r *http.Request, res *http.Response) bool {
+	if res.StatusCode != http.StatusUnauthorized {
+		return false
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.Token != nil && r.Header.Get("Authorization") == "Bearer "+o.Token.AccessToken {
+		o.Token = &OAuth2Token{RefreshToken: o.Token.RefreshToken}
+	}
+	return true
+}
+
+func (o *OAuth2) Scheme() string {
+	return "Bearer"
+}
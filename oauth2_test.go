@@ -0,0 +1,227 @@
+package go_requests
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// 令牌接口及资源接口, 令牌接口每次签发新令牌, 资源接口只接受最新令牌
+type oauth2Server struct {
+	*httptest.Server
+	expiresIn int
+
+	mu     sync.Mutex
+	issued int                 // 签发次数
+	grants []string            // 每次请求的grant_type
+	forms  []map[string]string // 每次请求的参数及客户端认证信息
+	latest string              // 最新令牌
+}
+
+func newOAuth2Server(expiresIn int) *oauth2Server {
+	s := newUnstartedOAuth2Server(expiresIn)
+	s.Start()
+	return s
+}
+
+func newUnstartedOAuth2Server(expiresIn int) *oauth2Server {
+	s := &oauth2Server{expiresIn: expiresIn}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		id, secret, _ := r.BasicAuth()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		form := map[string]string{"basic": id + ":" + secret}
+		for key := range r.PostForm {
+			form[key] = r.PostForm.Get(key)
+		}
+		s.forms = append(s.forms, form)
+		s.grants = append(s.grants, form["grant_type"])
+		if id != "app" && form["client_id"] != "app" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"invalid_client","error_description":"bad client"}`))
+			return
+		}
+		s.issued++
+		s.latest = fmt.Sprintf("token-%d", s.issued)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token":  s.latest,
+			"token_type":    "Bearer",
+			"expires_in":    s.expiresIn,
+			"refresh_token": fmt.Sprintf("refresh-%d", s.issued),
+		})
+	})
+	mux.HandleFunc("/api", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if r.Header.Get("Authorization") != "Bearer "+s.latest {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(s.latest))
+	})
+	s.Server = httptest.NewUnstartedServer(mux)
+	return s
+}
+
+// 作废当前令牌, 模拟令牌被服务端吊销
+func (s *oauth2Server) revoke() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latest = "revoked"
+}
+
+// 测试client_credentials授权并缓存令牌
+func TestOAuth2ClientCredentials(t *testing.T) {
+	server := newOAuth2Server(3600)
+	defer server.Close()
+
+	oauth2 := NewOAuth2(server.URL+"/token", "app", "s&cret", "read", "write")
+	session := NewSession(NewConfig().SetOAuth2(oauth2))
+	for i := 0; i < 3; i++ {
+		resp := session.Get(server.URL+"/api", nil)
+		if resp.StatusCode != http.StatusOK || resp.Text != "token-1" {
+			t.Fatalf("第%d次请求错误: %d %s", i+1, resp.StatusCode, resp.Text)
+		}
+	}
+	if server.issued != 1 {
+		t.Errorf("期望只获取1次令牌, 实际 %d", server.issued)
+	}
+	form := server.forms[0]
+	if form["grant_type"] != "client_credentials" || form["scope"] != "read write" || form["basic"] != "app:s%26cret" {
+		t.Errorf("令牌请求参数错误: %v", form)
+	}
+}
+
+// 测试会话保存及恢复后继续使用缓存的令牌
+func TestOAuth2SessionSave(t *testing.T) {
+	server := newOAuth2Server(3600)
+	defer server.Close()
+	path := filepath.Join(t.TempDir(), "session.json")
+
+	session := NewSession(nil).SetOAuth2(NewOAuth2(server.URL+"/token", "app", "secret"))
+	if resp := session.Get(server.URL+"/api", nil); resp.Text != "token-1" {
+		t.Fatalf("请求失败: %d %s", resp.StatusCode, resp.Text)
+	}
+	if err := session.Save(path); err != nil {
+		t.Fatalf("保存会话失败: %s", err)
+	}
+	loaded, err := LoadSession(path)
+	if err != nil {
+		t.Fatalf("恢复会话失败: %s", err)
+	}
+	if loaded.OAuth2 == nil || loaded.OAuth2.Token == nil || loaded.OAuth2.Token.RefreshToken != "refresh-1" {
+		t.Fatalf("期望恢复令牌及刷新令牌, 实际 %+v", loaded.OAuth2)
+	}
+	if resp := loaded.Get(server.URL+"/api", nil); resp.Text != "token-1" || server.issued != 1 {
+		t.Errorf("恢复的会话应使用缓存的令牌, 实际 %s, 签发%d次", resp.Text, server.issued)
+	}
+}
+
+// 测试令牌请求使用OAuth2.Config中的CA证书, Config同时设置该OAuth2时不递归认证
+func TestOAuth2Config(t *testing.T) {
+	server := newUnstartedOAuth2Server(3600)
+	server.StartTLS()
+	defer server.Close()
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	if _, err := NewOAuth2(server.URL+"/token", "app", "secret").GetToken(context.Background()); err == nil {
+		t.Fatal("未信任服务器证书时期望令牌请求失败")
+	}
+	config := NewConfig().SetTLSConfig(NewTLSConfig().SetCAPEM(string(caPEM))).SetTimeout(5000)
+	config.SetOAuth2(NewOAuth2(server.URL+"/token", "app", "secret").SetConfig(config))
+	resp, err := NewRequestWithConfig(config, "GET", server.URL+"/api").Do()
+	if err != nil || resp.Text != "token-1" {
+		t.Fatalf("期望使用Config中的CA证书获取令牌, 实际 %v %v", resp, err)
+	}
+}
+
+// 测试令牌即将过期时使用刷新令牌刷新
+func TestOAuth2RefreshBeforeExpiry(t *testing.T) {
+	server := newOAuth2Server(5) // 有效期小于默认提前刷新时间
+	defer server.Close()
+
+	oauth2 := NewOAuth2(server.URL+"/token", "app", "secret").SetPasswordGrant("kevin", "123456")
+	for i := 0; i < 2; i++ {
+		resp, err := NewRequest("GET", server.URL+"/api").SetOAuth2(oauth2).Do()
+		if err != nil || resp.StatusCode != http.StatusOK {
+			t.Fatalf("第%d次请求错误: %v", i+1, err)
+		}
+	}
+	if len(server.grants) != 2 || server.grants[0] != "password" || server.grants[1] != "refresh_token" {
+		t.Errorf("期望password及refresh_token授权, 实际 %v", server.grants)
+	}
+	if server.forms[0]["username"] != "kevin" || server.forms[1]["refresh_token"] != "refresh-1" {
+		t.Errorf("令牌请求参数错误: %v", server.forms)
+	}
+}
+
+// 测试请求返回401时刷新令牌并重发
+func TestOAuth2RefreshOnUnauthorized(t *testing.T) {
+	server := newOAuth2Server(3600)
+	defer server.Close()
+
+	oauth2 := NewOAuth2(server.URL+"/token", "app", "secret")
+	if _, err := NewRequest("GET", server.URL+"/api").SetOAuth2(oauth2).Do(); err != nil {
+		t.Fatalf("请求失败: %s", err)
+	}
+	server.revoke()
+	resp, err := NewRequest("POST", server.URL+"/api").SetOAuth2(oauth2).SetRawData("data").Do()
+	if err != nil || resp.StatusCode != http.StatusOK || resp.Text != "token-2" {
+		t.Fatalf("期望刷新令牌后重发成功, 实际 %v %v", resp, err)
+	}
+	if server.grants[1] != "refresh_token" {
+		t.Errorf("期望使用刷新令牌, 实际 %v", server.grants)
+	}
+}
+
+// 测试并发请求只获取一次令牌
+func TestOAuth2ConcurrentRefresh(t *testing.T) {
+	server := newOAuth2Server(3600)
+	defer server.Close()
+
+	oauth2 := NewOAuth2(server.URL+"/token", "app", "secret")
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if resp, err := NewRequest("GET", server.URL+"/api").SetOAuth2(oauth2).Do(); err != nil || resp.StatusCode != http.StatusOK {
+				t.Errorf("请求错误: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if server.issued != 1 {
+		t.Errorf("期望只获取1次令牌, 实际 %d", server.issued)
+	}
+}
+
+// 测试令牌接口返回错误
+func TestOAuth2Error(t *testing.T) {
+	server := newOAuth2Server(3600)
+	defer server.Close()
+
+	oauth2 := &OAuth2{TokenURL: server.URL + "/token", ClientID: "other", AuthInBody: true}
+	_, err := NewRequest("GET", server.URL+"/api").SetOAuth2(oauth2).Do()
+	var oauthErr *OAuth2Error
+	if !errors.As(err, &oauthErr) || oauthErr.Code != "invalid_client" || oauthErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("期望OAuth2Error, 实际 %v", err)
+	}
+	if server.forms[0]["client_id"] != "other" {
+		t.Errorf("AuthInBody时应在请求数据中发送client_id: %v", server.forms[0])
+	}
+	if _, err := oauth2.GetToken(context.Background()); err == nil {
+		t.Error("期望获取令牌失败")
+	}
+}
@@ -0,0 +1,141 @@
+package go_requests
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Progress 传输进度
+type Progress struct {
+	Transferred int64   // 已传输字节数
+	Total       int64   // 总字节数, 未知时为-1
+	Elapsed     float64 // 已用时间(秒)
+	Rate        float64 // 平均传输速率(字节/秒), 不含断点续传前已下载部分
+}
+
+// ProgressFunc 传输进度回调
+type ProgressFunc func(progress Progress)
+
+// 传输进度计数, 可并发使用
+type progressCounter struct {
+	mu       sync.Mutex
+	progress Progress
+	callback ProgressFunc
+	start    time.Time
+	initial  int64 // 开始计数前已传输的字节数
+}
+
+func newProgressCounter(progress Progress, callback ProgressFunc) *progressCounter {
+	return &progressCounter{progress: progress, callback: callback, start: time.Now(), initial: progress.Transferred}
+}
+
+func (counter *progressCounter) add(n int64) {
+	counter.mu.Lock()
+	defer counter.mu.Unlock()
+	counter.progress.Transferred += n
+	counter.progress.Elapsed = time.Since(counter.start).Seconds()
+	if counter.progress.Elapsed > 0 {
+		counter.progress.Rate = float64(counter.progress.Transferred-counter.initial) / counter.progress.Elapsed
+	}
+	if counter.callback != nil {
+		counter.callback(counter.progress)
+	}
+}
+
+// 带进度回调的写入
+type progressWriter struct {
+	writer  io.Writer
+	counter *progressCounter
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.counter.add(int64(n))
+	return n, err
+}
+
+// 统计进度并限速的读取, 用于请求数据及响应数据
+type meteredReader struct {
+	body    io.ReadCloser
+	ctx     context.Context
+	counter *progressCounter
+	limit   int64 // 限速, 单位 字节/秒, 小于等于0时不限速
+	start   time.Time
+	read    int64
+}
+
+func newMeteredReader(ctx context.Context, body io.ReadCloser, total int64, callback ProgressFunc, limit int64) *meteredReader {
+	if total <= 0 {
+		total = -1
+	}
+	return &meteredReader{
+		body:    body,
+		ctx:     ctx,
+		counter: newProgressCounter(Progress{Total: total}, callback),
+		limit:   limit,
+		start:   time.Now(),
+	}
+}
+
+func (r *meteredReader) Read(p []byte) (int, error) {
+	if r.limit > 0 && int64(len(p)) > r.limit {
+		p = p[:r.limit] // 单次最多读取1秒的数据量
+	}
+	n, err := r.body.Read(p)
+	if n <= 0 {
+		return n, err
+	}
+	r.read += int64(n)
+	r.counter.add(int64(n))
+	if r.limit > 0 {
+		// 按限速计算应耗时间, 超前时等待
+		wait := time.Duration(float64(r.read)/float64(r.limit)*float64(time.Second)) - time.Since(r.start)
+		if wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-r.ctx.Done():
+				timer.Stop()
+				return n, r.ctx.Err()
+			case <-timer.C:
+			}
+		}
+	}
+	return n, err
+}
+
+func (r *meteredReader) Close() error {
+	return r.body.Close()
+}
+
+// 统计请求数据上传进度并限速, 重试或重定向重放请求数据时重新统计
+func (req *Request) meterUpload(r *http.Request) {
+	if (req.UploadProgress == nil && req.UploadLimit <= 0) || r.Body == nil || r.Body == http.NoBody {
+		return
+	}
+	ctx, total, callback, limit := r.Context(), r.ContentLength, req.UploadProgress, req.UploadLimit
+	r.Body = newMeteredReader(ctx, r.Body, total, callback, limit)
+	if getBody := r.GetBody; getBody != nil {
+		r.GetBody = func() (io.ReadCloser, error) {
+			body, err := getBody()
+			if err != nil {
+				return nil, err
+			}
+			return newMeteredReader(ctx, body, total, callback, limit), nil
+		}
+	}
+}
+
+// 统计响应数据下载进度并限速
+func (req *Request) meterDownload(res *http.Response) {
+	if req.DownloadProgress == nil && req.DownloadLimit <= 0 {
+		return
+	}
+	ctx := context.Background()
+	if res.Request != nil {
+		ctx = res.Request.Context()
+	}
+	res.Body = newMeteredReader(ctx, res.Body, res.ContentLength, req.DownloadProgress, req.DownloadLimit)
+}
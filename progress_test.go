@@ -0,0 +1,82 @@
+package go_requests
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 测试上传文件进度
+func TestUploadProgress(t *testing.T) {
+	var received int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		received = len(body)
+	}))
+	defer server.Close()
+
+	var calls int
+	var last Progress
+	_, err := NewRequest("POST", server.URL).
+		SetUploadFiles(map[string]string{"file": "./testdata/logo.png"}).
+		SetUploadProgress(func(progress Progress) {
+			calls++
+			last = progress
+		}).Do()
+	if err != nil {
+		t.Fatalf("请求失败: %s", err)
+	}
+	if calls == 0 || last.Transferred != int64(received) || last.Total != int64(received) {
+		t.Errorf("上传进度错误: %+v, 服务端收到 %d", last, received)
+	}
+}
+
+// 测试通过Config设置下载进度及限速
+func TestDownloadProgressAndLimit(t *testing.T) {
+	content := strings.Repeat("a", 20000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		_, _ = w.Write([]byte(content))
+	}))
+	defer server.Close()
+
+	var last Progress
+	config := NewConfig().SetRateLimit(0, 50000).SetDownloadProgress(func(progress Progress) { last = progress })
+	start := time.Now()
+	resp, err := NewRequestWithConfig(config, "GET", server.URL).Do()
+	if err != nil {
+		t.Fatalf("请求失败: %s", err)
+	}
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond {
+		t.Errorf("限速50KB/s下载20KB应至少耗时0.4秒, 实际 %s", elapsed)
+	}
+	if resp.Text != content {
+		t.Error("响应内容错误")
+	}
+	if last.Transferred != int64(len(content)) || last.Total != int64(len(content)) || last.Rate <= 0 || last.Elapsed <= 0 {
+		t.Errorf("下载进度错误: %+v", last)
+	}
+}
+
+// 测试上传限速
+func TestUploadLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	start := time.Now()
+	_, err := NewRequest("PUT", server.URL).
+		SetBodyBytes([]byte(strings.Repeat("a", 20000)), "").
+		SetRateLimit(50000, 0).Do()
+	if err != nil {
+		t.Fatalf("请求失败: %s", err)
+	}
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond {
+		t.Errorf("限速50KB/s上传20KB应至少耗时0.4秒, 实际 %s", elapsed)
+	}
+}
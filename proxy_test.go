@@ -0,0 +1,311 @@
+package go_requests
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// HTTP代理服务, 记录收到的请求, 校验Proxy-Authorization并支持CONNECT隧道
+type proxyServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []*http.Request
+}
+
+func newProxyServer(auth string) *proxyServer {
+	p := &proxyServer{}
+	p.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p.mu.Lock()
+		p.requests = append(p.requests, r)
+		p.mu.Unlock()
+		if auth != "" && r.Header.Get("Proxy-Authorization") != auth {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		if r.Method == http.MethodConnect {
+			target, err := net.Dial("tcp", r.Host)
+			if err != nil {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			go func() {
+				_, _ = io.Copy(target, conn)
+				_ = target.Close()
+			}()
+			_, _ = io.Copy(conn, target)
+			_ = conn.Close()
+			return
+		}
+		_, _ = w.Write([]byte("proxy " + r.URL.String()))
+	}))
+	return p
+}
+
+func (p *proxyServer) count() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.requests)
+}
+
+// 测试HTTP代理认证, 代理地址中的用户名及密码与单独设置的用户名及密码
+func TestProxyAuth(t *testing.T) {
+	p := newProxyServer("Basic a2V2aW46MTIzNDU2")
+	defer p.Close()
+	proxyUrl := strings.Replace(p.URL, "http://", "http://kevin:123456@", 1)
+
+	resp, err := NewRequest("GET", "http://example.invalid/get").SetProxy(proxyUrl).Do()
+	if err != nil || resp.Text != "proxy http://example.invalid/get" {
+		t.Fatalf("期望通过代理请求, 实际 %v %v", resp, err)
+	}
+	config := NewConfig().SetProxyConfig(NewProxyConfig(p.URL).SetAuth("kevin", "123456"))
+	resp, err = NewRequestWithConfig(config, "GET", "http://example.invalid/get").Do()
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("期望代理认证成功, 实际 %v %v", resp, err)
+	}
+	resp, _ = NewRequest("GET", "http://example.invalid/get").SetProxy(p.URL).Do()
+	if resp.StatusCode != http.StatusProxyAuthRequired {
+		t.Errorf("未认证时期望407, 实际 %d", resp.StatusCode)
+	}
+}
+
+// 测试通过HTTP代理访问https地址时CONNECT请求携带认证信息及自定义请求头
+func TestProxyConnectHeaders(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	p := newProxyServer("Basic a2V2aW46MTIzNDU2")
+	defer p.Close()
+
+	proxyConfig := NewProxyConfig(p.URL).SetAuth("kevin", "123456").SetConnectHeader("X-Tenant", "t1")
+	resp, err := NewRequest("GET", server.URL).SetProxyConfig(proxyConfig).SetNoVerify(true).Do()
+	if err != nil || resp.Text != "ok" {
+		t.Fatalf("期望通过CONNECT隧道请求, 实际 %v %v", resp, err)
+	}
+	if p.count() != 1 || p.requests[0].Method != http.MethodConnect || p.requests[0].Header.Get("X-Tenant") != "t1" {
+		t.Errorf("CONNECT请求不正确: %v", p.requests)
+	}
+}
+
+// 测试按主机规则选择代理及NoProxy
+func TestProxyRules(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("direct"))
+	}))
+	defer server.Close()
+	p1 := newProxyServer("")
+	defer p1.Close()
+	p2 := newProxyServer("")
+	defer p2.Close()
+
+	proxyConfig := NewProxyConfig(p1.URL).
+		AddRule("*.example.com", p2.URL).
+		AddRule("internal.example.com", "direct").
+		SetNoProxy("127.0.0.0/8", "localhost")
+	config := NewConfig().SetProxyConfig(proxyConfig)
+	cases := map[string]string{
+		"http://other.invalid/":   "proxy http://other.invalid/",
+		"http://api.example.com/": "proxy http://api.example.com/",
+		server.URL:                "direct",
+		strings.Replace(server.URL, "127.0.0.1", "localhost", 1): "direct",
+	}
+	for rawUrl, expected := range cases {
+		resp, err := NewRequestWithConfig(config, "GET", rawUrl).Do()
+		if err != nil || resp.Text != expected {
+			t.Errorf("%s: 期望 %q, 实际 %v %v", rawUrl, expected, resp, err)
+		}
+	}
+	if p1.count() != 1 || p2.count() != 1 {
+		t.Errorf("代理请求次数错误: %d %d", p1.count(), p2.count())
+	}
+	_, err := NewRequestWithConfig(config, "GET", "http://internal.example.com/").Do()
+	if err == nil || p2.count() != 1 {
+		t.Errorf("internal.example.com应直连, 实际 %v", err)
+	}
+
+	if _, err := NewRequest("GET", server.URL).SetProxy("ftp://127.0.0.1:21").Do(); !errors.Is(err, ErrTransport) {
+		t.Errorf("期望不支持的代理协议错误, 实际 %v", err)
+	}
+}
+
+// 测试匹配不使用代理的主机
+func TestMatchProxyHost(t *testing.T) {
+	cases := []struct {
+		pattern, host, port string
+		expected            bool
+	}{
+		{"example.com", "example.com", "80", true},
+		{"example.com", "api.example.com", "80", true},
+		{"example.com", "badexample.com", "80", false},
+		{".example.com", "api.example.com", "443", true},
+		{"*.example.com", "api.example.com", "443", true},
+		{"example.com:8080", "example.com", "80", false},
+		{"example.com:8080", "example.com", "8080", true},
+		{"10.0.0.0/8", "10.1.2.3", "80", true},
+		{"10.0.0.0/8", "example.com", "80", false},
+		{"::1", "::1", "80", true},
+		{"[::1]:443", "::1", "443", true},
+		{"*", "anything", "80", true},
+	}
+	for _, c := range cases {
+		if matchProxyHost(c.pattern, c.host, c.port) != c.expected {
+			t.Errorf("%s 匹配 %s:%s 期望 %v", c.pattern, c.host, c.port, c.expected)
+		}
+	}
+}
+
+// 测试使用环境变量中的代理配置
+func TestProxyFromEnv(t *testing.T) {
+	p := newProxyServer("")
+	defer p.Close()
+	t.Setenv("HTTP_PROXY", p.URL)
+	t.Setenv("NO_PROXY", "skip.invalid")
+
+	config := NewConfig().SetProxyConfig(NewProxyConfig("").SetFromEnv(true))
+	resp, err := NewRequestWithConfig(config, "GET", "http://env.invalid/").Do()
+	if err != nil || resp.Text != "proxy http://env.invalid/" {
+		t.Fatalf("期望使用HTTP_PROXY, 实际 %v %v", resp, err)
+	}
+	if _, err := NewRequestWithConfig(config, "GET", "http://skip.invalid/").Do(); err == nil || p.count() != 1 {
+		t.Errorf("NO_PROXY中的主机应直连, 实际 %v", err)
+	}
+	if _, err := NewRequest("GET", "http://env.invalid/").Do(); err == nil || p.count() != 1 {
+		t.Errorf("未启用FromEnv时不应使用环境变量, 实际 %v", err)
+	}
+}
+
+// SOCKS5代理服务, 支持无认证及用户名密码认证, 记录请求的目标地址
+type socks5Server struct {
+	net.Listener
+	username string
+	password string
+	mu       sync.Mutex
+	targets  []string
+}
+
+func newSocks5Server(t *testing.T, username, password string) *socks5Server {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &socks5Server{Listener: ln, username: username, password: password}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *socks5Server) serve(conn net.Conn) {
+	defer conn.Close()
+	buf := make([]byte, 262)
+	// 协商认证方式
+	if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+		return
+	}
+	if _, err := io.ReadFull(conn, buf[:buf[1]]); err != nil {
+		return
+	}
+	if s.username == "" {
+		_, _ = conn.Write([]byte{5, 0})
+	} else {
+		_, _ = conn.Write([]byte{5, 2})
+		if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+			return
+		}
+		username := make([]byte, buf[1])
+		_, _ = io.ReadFull(conn, username)
+		_, _ = io.ReadFull(conn, buf[:1])
+		password := make([]byte, buf[0])
+		_, _ = io.ReadFull(conn, password)
+		if string(username) != s.username || string(password) != s.password {
+			_, _ = conn.Write([]byte{1, 1})
+			return
+		}
+		_, _ = conn.Write([]byte{1, 0})
+	}
+	// CONNECT请求
+	if _, err := io.ReadFull(conn, buf[:4]); err != nil {
+		return
+	}
+	var host string
+	switch buf[3] {
+	case 1:
+		_, _ = io.ReadFull(conn, buf[:4])
+		host = net.IP(buf[:4]).String()
+	case 3:
+		_, _ = io.ReadFull(conn, buf[:1])
+		name := make([]byte, buf[0])
+		_, _ = io.ReadFull(conn, name)
+		host = string(name)
+	default:
+		return
+	}
+	_, _ = io.ReadFull(conn, buf[:2])
+	target := net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(buf[:2]))))
+	s.mu.Lock()
+	s.targets = append(s.targets, target)
+	s.mu.Unlock()
+	upstream, err := net.Dial("tcp", target)
+	if err != nil {
+		_, _ = conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer upstream.Close()
+	_, _ = conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+	go func() {
+		_, _ = io.Copy(upstream, conn)
+	}()
+	_, _ = io.Copy(conn, upstream)
+}
+
+// 测试socks5(本地解析域名)及socks5h(由代理解析域名)代理
+func TestSocks5Proxy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	s := newSocks5Server(t, "kevin", "123456")
+	defer s.Close()
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	targetUrl := "http://localhost:" + port
+
+	resp, err := NewRequest("GET", targetUrl).SetProxy("socks5h://kevin:123456@" + s.Addr().String()).Do()
+	if err != nil || resp.Text != "ok" {
+		t.Fatalf("socks5h代理请求失败: %v %v", resp, err)
+	}
+	proxyConfig := NewProxyConfig("socks5://"+s.Addr().String()).SetAuth("kevin", "123456")
+	resp, err = NewRequest("GET", targetUrl).SetProxyConfig(proxyConfig).Do()
+	if err != nil || resp.Text != "ok" {
+		t.Fatalf("socks5代理请求失败: %v %v", resp, err)
+	}
+	s.mu.Lock()
+	targets := s.targets
+	s.mu.Unlock()
+	if len(targets) != 2 || targets[0] != "localhost:"+port {
+		t.Fatalf("socks5h应由代理解析域名: %v", targets)
+	}
+	if host, _, _ := net.SplitHostPort(targets[1]); net.ParseIP(host) == nil {
+		t.Errorf("socks5应在本地解析域名: %v", targets)
+	}
+
+	_, err = NewRequest("GET", targetUrl).SetProxy("socks5h://kevin:wrong@" + s.Addr().String()).Do()
+	if err == nil {
+		t.Error("期望SOCKS5认证失败")
+	}
+}
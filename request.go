@@ -2,17 +2,13 @@ package go_requests
 
 import (
 	"bytes"
-	"crypto/tls"
+	"context"
 	"encoding/json"
 	"fmt"
-	"golang.org/x/net/http2"
 	"io"
 	"io/ioutil"
-	"mime/multipart"
 	"net/http"
 	"net/url"
-	"os"
-	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -25,21 +21,64 @@ var Ch chan Response = make(chan Response, 10)
 type Request struct {
 	Config *Config `json:"config"` // 请求配置
 
-	Method      string            `json:"method"`          // 请求方法
-	Url         string            `json:"url"`             // 请求url
-	Params      map[string]string `json:"params"`          // Query参数
-	Headers     map[string]string `json:"headers"`         // 请求头
-	Cookies     map[string]string `json:"cookies"`         // Cookies
-	Data        map[string]string `json:"data"`            // 表单格式请求数据
-	Json        string            `json:"json"`            // JSON格式请求数据
-	Files       map[string]string `json:"files"`           // mutipart/form-data需要上传的文体 Files
-	Raw         string            `json:"raw"`             // 原始请求数据
-	Auth        []string          `json:"auth"`            // BasicAuth授权用户名及密码
-	Proxy       string            `json:"proxy"`           // 代理地址 例如  "http://127.0.0.1:8888"
-	Timeout     int               `json:"timeout"`         // 超时时间，单位 毫秒
-	NoRedirects bool              `json:"allow_redirects"` // 关闭重定向, 默认开启
-	NoVerify    bool              `json:"no_verify"`       // 跳过TLS证书验证，默认不跳过
-	HTTP2       bool              `json:"http_2"`          // 是否启用HTTP2，默认不启用，受Config影响
+	Method       string            `json:"method"`          // 请求方法
+	Url          string            `json:"url"`             // 请求url
+	Params       map[string]string `json:"params"`          // Query参数
+	Headers      map[string]string `json:"headers"`         // 请求头
+	Cookies      map[string]string `json:"cookies"`         // Cookies
+	Data         map[string]string `json:"data"`            // 表单格式请求数据
+	ParamValues  url.Values        `json:"param_values"`    // 多值Query参数，如 tag=a&tag=b
+	HeaderValues http.Header       `json:"header_values"`   // 多值请求头
+	DataValues   url.Values        `json:"data_values"`     // 多值表单数据
+	Json         string            `json:"json"`            // JSON格式请求数据
+	JsonValue    interface{}       `json:"-"`               // 需要序列化的JSON请求数据，优先于Json
+	JsonOptions  *JsonOptions      `json:"json_options"`    // JSON序列化配置，受Config影响
+	Files        map[string]string `json:"files"`           // mutipart/form-data需要上传的文体 Files
+	Parts        []*FormPart       `json:"parts"`           // mutipart/form-data各部分，支持内存数据、io.Reader及自定义文件名、Content-Type
+	Raw          string            `json:"raw"`             // 原始请求数据
+	Body         io.Reader         `json:"-"`               // 流式请求数据，优先于其他请求数据
+	BodyLength   int64             `json:"-"`               // 流式请求数据长度，小于等于0时为未知长度，Body支持Seek时自动计算
+	BodyFile     string            `json:"body_file"`       // 以二进制流发送的单个文件路径
+	BodyValue    interface{}       `json:"body_value"`      // 按BodyType使用已注册的编码器编码的请求数据
+	BodyType     string            `json:"body_type"`       // BodyValue的Content-Type，默认 application/json
+	Auth         []string          `json:"auth"`            // BasicAuth授权用户名及密码
+	DigestAuth   *DigestAuth       `json:"digest_auth"`     // Digest摘要认证，受Config影响
+	OAuth2       *OAuth2           `json:"oauth2"`          // OAuth2认证，自动获取及刷新令牌，受Config影响
+	Signer       Signer            `json:"-"`               // 请求签名，如AWS SigV4及HMAC签名，受Config影响
+	Proxy        string            `json:"proxy"`           // 代理地址 例如  "http://127.0.0.1:8888"，支持 socks5:// 及 socks5h://
+	ProxyConfig  *ProxyConfig      `json:"proxy_config"`    // 代理配置，环境变量、按主机规则、NoProxy、代理认证及CONNECT请求头，受Config影响
+	Timeout      int               `json:"timeout"`         // 超时时间，单位 毫秒
+	NoRedirects  bool              `json:"allow_redirects"` // 关闭重定向, 默认开启
+	NoVerify     bool              `json:"no_verify"`       // 跳过TLS证书验证，默认不跳过
+	HTTP2        bool              `json:"http_2"`          // 是否启用HTTP2，默认不启用，受Config影响
+	Retry        *RetryPolicy      `json:"retry"`           // 重试策略，默认不重试，受Config影响
+	TLS          *TLSConfig        `json:"tls"`             // TLS配置，客户端证书及CA证书等，受Config影响
+	Stream       bool              `json:"stream"`          // 流式响应，不读取响应数据，通过Response.Body读取
+	UseNumber    bool              `json:"use_number"`      // Response.Decode解码JSON时数字使用json.Number，避免大整数丢失精度，受Config影响
+	Compress     string            `json:"compress"`        // 请求数据压缩方式，支持 "gzip" 及 "zstd"，默认不压缩，受Config影响
+
+	KeepRawContent bool `json:"keep_raw_content"` // 保留压缩的原始响应数据到Response.RawContent，受Config影响
+
+	RequestCharset  string `json:"request_charset"`  // 表单及Raw请求数据的字符集，如 "gbk"，默认UTF-8，受Config影响
+	ResponseCharset string `json:"response_charset"` // 指定响应文本字符集，默认按Content-Type、BOM及HTML meta标签检测，受Config影响
+
+	Netrc            bool   `json:"netrc"` // 未设置Auth时从netrc文件(NETRC环境变量或~/.netrc)查找用户名及密码，受Config影响
+	CredentialHelper string `json:"-"`     // 未设置Auth时调用的凭证助手命令，输入输出格式与git credential相同，只能在代码中设置，受Config影响
+
+	UploadProgress   ProgressFunc `json:"-"`              // 上传进度回调，受Config影响
+	DownloadProgress ProgressFunc `json:"-"`              // 下载进度回调，受Config影响
+	UploadLimit      int64        `json:"upload_limit"`   // 上传限速，单位 字节/秒，默认不限速，受Config影响
+	DownloadLimit    int64        `json:"download_limit"` // 下载限速，单位 字节/秒，默认不限速，受Config影响
+
+	Authenticator Authenticator `json:"-"` // 认证方式，优先于OAuth2及DigestAuth，受Config影响
+
+	UnixSocket  string          `json:"unix_socket"` // 连接的Unix socket，如 "/var/run/docker.sock" 或 "unix:///var/run/docker.sock"，也可使用 http+unix:// 地址，受Config影响
+	DialContext DialContextFunc `json:"-"`           // 自定义拨号函数，设置后LocalAddr及PreferIP不生效，不复用连接，需要复用连接时请在Config中设置
+	LocalAddr   string          `json:"local_addr"`  // 连接使用的本地IP地址，受Config影响
+	PreferIP    string          `json:"prefer_ip"`   // 优先连接的IP版本，"ipv4" 或 "ipv6"，失败时尝试其他地址，受Config影响
+
+	jar         http.CookieJar  // 会话Cookie存储，由Session设置
+	authHeaders map[string]bool // Authenticator添加或修改的请求头，重定向到其他主机时删除
 }
 
 func NewRequest(method, url string) *Request {
@@ -63,6 +102,34 @@ func (req *Request) SetHeaders(headers map[string]string) *Request {
 	return req
 }
 
+// AddParam 添加Query参数，同名参数保留多个值
+func (req *Request) AddParam(key, value string) *Request {
+	if req.ParamValues == nil {
+		req.ParamValues = url.Values{}
+	}
+	req.ParamValues.Add(key, value)
+	return req
+}
+
+func (req *Request) SetParamValues(params url.Values) *Request {
+	req.ParamValues = params
+	return req
+}
+
+// AddHeader 添加请求头，同名请求头保留多个值
+func (req *Request) AddHeader(key, value string) *Request {
+	if req.HeaderValues == nil {
+		req.HeaderValues = http.Header{}
+	}
+	req.HeaderValues.Add(key, value)
+	return req
+}
+
+func (req *Request) SetHeaderValues(headers http.Header) *Request {
+	req.HeaderValues = headers
+	return req
+}
+
 func (req *Request) SetCookies(cookies map[string]string) *Request {
 	req.Cookies = cookies
 	return req
@@ -73,6 +140,20 @@ func (req *Request) SetFormData(data map[string]string) *Request {
 	return req
 }
 
+// AddFormData 添加表单字段，同名字段保留多个值
+func (req *Request) AddFormData(key, value string) *Request {
+	if req.DataValues == nil {
+		req.DataValues = url.Values{}
+	}
+	req.DataValues.Add(key, value)
+	return req
+}
+
+func (req *Request) SetFormValues(data url.Values) *Request {
+	req.DataValues = data
+	return req
+}
+
 func (req *Request) SetJsonData(json string) *Request {
 	req.Json = json
 	return req
@@ -83,6 +164,39 @@ func (req *Request) SetRawData(raw string) *Request {
 	return req
 }
 
+// SetBodyReader 设置流式请求数据，length小于等于0时为未知长度，使用chunked编码发送
+// contentType为空时默认使用 application/octet-stream
+func (req *Request) SetBodyReader(body io.Reader, length int64, contentType string) *Request {
+	req.Body = body
+	req.BodyLength = length
+	if contentType != "" {
+		req.SetContentType(contentType)
+	}
+	return req
+}
+
+// SetBodyBytes 设置二进制请求数据
+func (req *Request) SetBodyBytes(body []byte, contentType string) *Request {
+	return req.SetBodyReader(bytes.NewReader(body), int64(len(body)), contentType)
+}
+
+// SetBodyFile 设置以二进制流发送的文件，不会将文件全部读入内存
+func (req *Request) SetBodyFile(filePath string, contentType string) *Request {
+	req.BodyFile = filePath
+	if contentType != "" {
+		req.SetContentType(contentType)
+	}
+	return req
+}
+
+// SetBody 设置请求数据，发送时按contentType使用已注册的编码器编码，支持JSON、XML、YAML及表单
+// contentType为空时使用 application/json，可通过RegisterEncoder注册MessagePack、Protobuf等格式
+func (req *Request) SetBody(v interface{}, contentType string) *Request {
+	req.BodyValue = v
+	req.BodyType = contentType
+	return req
+}
+
 func (req *Request) SetUploadFiles(files map[string]string) *Request {
 	req.Files = files
 	return req
@@ -93,6 +207,42 @@ func (req *Request) SetBasicAuth(auth []string) *Request {
 	return req
 }
 
+// SetDigestAuth 设置Digest摘要认证用户名及密码, 收到401质询后自动认证
+func (req *Request) SetDigestAuth(username, password string) *Request {
+	req.DigestAuth = NewDigestAuth(username, password)
+	return req
+}
+
+// SetOAuth2 设置OAuth2认证, 发送请求时自动获取令牌并添加Bearer请求头
+func (req *Request) SetOAuth2(oauth2 *OAuth2) *Request {
+	req.OAuth2 = oauth2
+	return req
+}
+
+// SetAuthenticator 设置认证方式, 如 BasicAuth、BearerAuth、APIKeyAuth 及 AuthChain
+func (req *Request) SetAuthenticator(auth Authenticator) *Request {
+	req.Authenticator = auth
+	return req
+}
+
+// SetNetrc 未设置Auth时从netrc文件查找用户名及密码
+func (req *Request) SetNetrc(enable bool) *Request {
+	req.Netrc = enable
+	return req
+}
+
+// SetCredentialHelper 设置凭证助手命令, 如 "git credential fill"
+func (req *Request) SetCredentialHelper(command string) *Request {
+	req.CredentialHelper = command
+	return req
+}
+
+// SetSigner 设置请求签名, 在请求头组装完成后签名
+func (req *Request) SetSigner(signer Signer) *Request {
+	req.Signer = signer
+	return req
+}
+
 func (req *Request) SetTimeout(timeout int) *Request {
 	req.Timeout = timeout
 	return req
@@ -103,6 +253,36 @@ func (req *Request) SetProxy(proxy string) *Request {
 	return req
 }
 
+// SetProxyConfig 设置代理配置
+func (req *Request) SetProxyConfig(proxyConfig *ProxyConfig) *Request {
+	req.ProxyConfig = proxyConfig
+	return req
+}
+
+// SetUnixSocket 通过Unix socket发送请求, 如 "/var/run/docker.sock"
+func (req *Request) SetUnixSocket(socket string) *Request {
+	req.UnixSocket = socket
+	return req
+}
+
+// SetDialContext 设置自定义拨号函数
+func (req *Request) SetDialContext(dial DialContextFunc) *Request {
+	req.DialContext = dial
+	return req
+}
+
+// SetLocalAddr 设置连接使用的本地IP地址
+func (req *Request) SetLocalAddr(localAddr string) *Request {
+	req.LocalAddr = localAddr
+	return req
+}
+
+// SetPreferIP 设置优先连接的IP版本, "ipv4" 或 "ipv6"
+func (req *Request) SetPreferIP(version string) *Request {
+	req.PreferIP = version
+	return req
+}
+
 func (req *Request) SetNoRedirects(enable bool) *Request {
 	req.NoRedirects = enable
 	return req
@@ -118,6 +298,64 @@ func (req *Request) EnableHttp2(enable bool) *Request {
 	return req
 }
 
+func (req *Request) SetRetry(policy *RetryPolicy) *Request {
+	req.Retry = policy
+	return req
+}
+
+func (req *Request) SetTLSConfig(tlsConfig *TLSConfig) *Request {
+	req.TLS = tlsConfig
+	return req
+}
+
+// SetStream 设置流式响应，响应数据需通过Response.Body读取，读取后需调用Response.Close
+func (req *Request) SetStream(enable bool) *Request {
+	req.Stream = enable
+	return req
+}
+
+// SetCompress 设置请求数据压缩方式，支持 "gzip" 及 "zstd"
+func (req *Request) SetCompress(compress string) *Request {
+	req.Compress = compress
+	return req
+}
+
+// SetKeepRawContent 设置保留压缩的原始响应数据
+func (req *Request) SetKeepRawContent(enable bool) *Request {
+	req.KeepRawContent = enable
+	return req
+}
+
+// SetCharset 设置表单及Raw请求数据的字符集及响应文本字符集，为空时分别使用UTF-8及自动检测
+func (req *Request) SetCharset(requestCharset, responseCharset string) *Request {
+	req.RequestCharset = requestCharset
+	req.ResponseCharset = responseCharset
+	return req
+}
+
+// SetUseNumber 设置Response.Decode解码JSON时数字使用json.Number
+func (req *Request) SetUseNumber(enable bool) *Request {
+	req.UseNumber = enable
+	return req
+}
+
+func (req *Request) SetUploadProgress(callback ProgressFunc) *Request {
+	req.UploadProgress = callback
+	return req
+}
+
+func (req *Request) SetDownloadProgress(callback ProgressFunc) *Request {
+	req.DownloadProgress = callback
+	return req
+}
+
+// SetRateLimit 设置上传及下载限速，单位 字节/秒，0表示不限速
+func (req *Request) SetRateLimit(uploadLimit, downloadLimit int64) *Request {
+	req.UploadLimit = uploadLimit
+	req.DownloadLimit = downloadLimit
+	return req
+}
+
 func (req *Request) SetContentType(contentType string) *Request {
 	req.SetHeaders(map[string]string{"Content-Type": contentType})
 	return req
@@ -128,6 +366,14 @@ func (req *Request) SetBearerToken(token string) *Request {
 	return req
 }
 
+// 拼接Config中BaseUrl后的完整请求地址
+func (req *Request) fullUrl() string {
+	if req.Config != nil && req.Config.BaseUrl != "" && !strings.HasPrefix(req.Url, "http") {
+		return fmt.Sprintf("%s%s", req.Config.BaseUrl, req.Url)
+	}
+	return req.Url
+}
+
 // 处理Config配置
 func (req *Request) handleConfig() {
 	// 处理BaseUrl
@@ -135,41 +381,42 @@ func (req *Request) handleConfig() {
 	if config == nil {
 		return
 	}
-	if config.BaseUrl != "" && !strings.HasPrefix(req.Url, "http") {
-		req.Url = fmt.Sprintf("%s%s", config.BaseUrl, req.Url)
-	}
+	req.Url = req.fullUrl()
 	// 处理默认Params
 	if config.Params != nil {
-		if req.Params != nil {
-			for key, value := range config.Params {
-				if _, ok := req.Params[key]; !ok {
-					req.Params[key] = value
-				}
+		if req.Params == nil {
+			req.Params = map[string]string{}
+		}
+		for key, value := range config.Params {
+			if _, ok := req.Params[key]; !ok {
+				req.Params[key] = value
 			}
 		}
-		req.Params = config.Params
 	}
 	// 处理默认请求头
 	if config.Headers != nil {
-		if req.Headers != nil {
-			for key, value := range config.Headers {
-				if _, ok := req.Headers[key]; !ok {
-					req.Headers[key] = value
-				}
+		if req.Headers == nil {
+			req.Headers = map[string]string{}
+		}
+		for key, value := range config.Headers {
+			if _, ok := req.Headers[key]; !ok {
+				req.Headers[key] = value
 			}
 		}
-		req.Headers = config.Headers
 	}
+	// 处理默认多值Params及请求头
+	req.ParamValues = mergeValues(req.ParamValues, config.ParamValues, req.Params)
+	req.HeaderValues = mergeHeader(req.HeaderValues, config.HeaderValues, req.Headers)
 	// 处理默认Cookies
 	if config.Cookies != nil {
-		if req.Cookies != nil {
-			for key, value := range config.Cookies {
-				if _, ok := req.Cookies[key]; !ok {
-					req.Cookies[key] = value
-				}
+		if req.Cookies == nil {
+			req.Cookies = map[string]string{}
+		}
+		for key, value := range config.Cookies {
+			if _, ok := req.Cookies[key]; !ok {
+				req.Cookies[key] = value
 			}
 		}
-		req.Cookies = config.Cookies
 	}
 	// 处理默认Timeout
 	if config.Timeout > 0 && req.Timeout == 0 {
@@ -179,6 +426,29 @@ func (req *Request) handleConfig() {
 	if config.Auth != nil && len(config.Auth) == 2 {
 		req.Auth = config.Auth
 	}
+	// 处理默认DigestAuth, 共享同一DigestAuth的请求复用nonce
+	if config.DigestAuth != nil && req.DigestAuth == nil {
+		req.DigestAuth = config.DigestAuth
+	}
+	// 处理默认OAuth2, 共享同一OAuth2的请求复用令牌
+	if config.OAuth2 != nil && req.OAuth2 == nil {
+		req.OAuth2 = config.OAuth2
+	}
+	// 处理默认凭证查找方式
+	if config.Netrc {
+		req.Netrc = true
+	}
+	if config.CredentialHelper != "" && req.CredentialHelper == "" {
+		req.CredentialHelper = config.CredentialHelper
+	}
+	// 处理默认认证方式
+	if config.Authenticator != nil && req.Authenticator == nil {
+		req.Authenticator = config.Authenticator
+	}
+	// 处理默认请求签名
+	if config.Signer != nil && req.Signer == nil {
+		req.Signer = config.Signer
+	}
 	// 处理默认是否开启HTTP2
 	if config.HTTP2 == true {
 		req.HTTP2 = true
@@ -187,12 +457,68 @@ func (req *Request) handleConfig() {
 	if config.Proxy != "" {
 		req.Proxy = config.Proxy
 	}
+	if config.ProxyConfig != nil && req.ProxyConfig == nil {
+		req.ProxyConfig = config.ProxyConfig
+	}
+	// 处理默认拨号配置, Config中的DialContext在getDialer中使用, 以便按Config复用连接
+	if config.UnixSocket != "" && req.UnixSocket == "" {
+		req.UnixSocket = config.UnixSocket
+	}
+	if config.LocalAddr != "" && req.LocalAddr == "" {
+		req.LocalAddr = config.LocalAddr
+	}
+	if config.PreferIP != "" && req.PreferIP == "" {
+		req.PreferIP = config.PreferIP
+	}
+	// 处理默认TLS配置
+	if config.TLS != nil && req.TLS == nil {
+		req.TLS = config.TLS
+	}
+	// 处理默认重试策略
+	if config.Retry != nil && req.Retry == nil {
+		req.Retry = config.Retry
+	}
+	// 处理默认压缩配置
+	if config.Compress != "" && req.Compress == "" {
+		req.Compress = config.Compress
+	}
+	if config.KeepRawContent {
+		req.KeepRawContent = true
+	}
+	// 处理默认字符集
+	if config.RequestCharset != "" && req.RequestCharset == "" {
+		req.RequestCharset = config.RequestCharset
+	}
+	if config.ResponseCharset != "" && req.ResponseCharset == "" {
+		req.ResponseCharset = config.ResponseCharset
+	}
+	// 处理默认JSON序列化配置
+	if config.JsonOptions != nil && req.JsonOptions == nil {
+		req.JsonOptions = config.JsonOptions
+	}
+	// 处理默认JSON数字解码方式
+	if config.UseNumber {
+		req.UseNumber = true
+	}
+	// 处理默认进度回调及限速
+	if config.UploadProgress != nil && req.UploadProgress == nil {
+		req.UploadProgress = config.UploadProgress
+	}
+	if config.DownloadProgress != nil && req.DownloadProgress == nil {
+		req.DownloadProgress = config.DownloadProgress
+	}
+	if config.UploadLimit > 0 && req.UploadLimit == 0 {
+		req.UploadLimit = config.UploadLimit
+	}
+	if config.DownloadLimit > 0 && req.DownloadLimit == 0 {
+		req.DownloadLimit = config.DownloadLimit
+	}
 }
 
 // 处理请求方法
 func (req *Request) getMethod() string {
 	if req.Method == "" {
-		if req.Raw == "" && req.Json == "" && (req.Data == nil || len(req.Data) == 0) && (req.Files == nil || len(req.Files) == 0) {
+		if req.Body == nil && req.BodyFile == "" && req.BodyValue == nil && req.Raw == "" && req.Json == "" && req.JsonValue == nil && len(req.Data) == 0 && len(req.DataValues) == 0 && len(req.Files) == 0 && len(req.Parts) == 0 {
 			req.Method = "GET" // 无任何数据是默认请求方法GET
 		} else {
 			req.Method = "POST" // 有数据是默认请求方法是POST
@@ -202,84 +528,93 @@ func (req *Request) getMethod() string {
 }
 
 // 组装URL
-func (req *Request) getUrl() string {
-	if req.Params != nil && len(req.Params) > 0 {
-		urlValues := url.Values{}
-		Url, err := url.Parse(req.Url) // todo 处理err
-		if err != nil {
-			fmt.Printf("解析URL\"%s\"失败: %s\n", req.Url, err)
-		}
+func (req *Request) getUrl() (string, error) {
+	// 处理 http+unix:// 地址
+	socket, rawUrl, ok, err := parseUnixUrl(req.Url)
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		req.UnixSocket = socket
+	}
+	Url, err := url.Parse(rawUrl)
+	if err != nil {
+		return "", err
+	}
+	if Url.Scheme == "" || Url.Host == "" {
+		return "", fmt.Errorf("\"%s\"缺少协议或主机", req.Url)
+	}
+	if len(req.Params) > 0 || len(req.ParamValues) > 0 {
+		urlValues := Url.Query() // 合并url中已有的Query参数
 		for key, value := range req.Params {
 			urlValues.Set(key, value)
 		}
+		for key, values := range req.ParamValues {
+			for _, value := range values {
+				urlValues.Add(key, value)
+			}
+		}
 		Url.RawQuery = urlValues.Encode()
-		return Url.String()
+		return Url.String(), nil
 	}
-	return req.Url
+	return rawUrl, nil
 }
 
 // 组装请求数据
-func (req *Request) getData() io.Reader {
+func (req *Request) getData() (io.Reader, error) {
 	var reqBody string
 	if req.Headers == nil {
 		req.Headers = map[string]string{}
 	}
+	// 处理流式请求数据
+	stream, err := req.getStream()
+	if err != nil {
+		return nil, err
+	}
+	if stream != nil {
+		return stream, nil
+	}
+	// 处理需要编码的请求数据
+	if req.BodyValue != nil {
+		return req.encodeBody()
+	}
 	// 处理Raw格式请求，需要自行添加请求头Content-Type
 	if req.Raw != "" {
-		reqBody = req.Raw
-		return strings.NewReader(reqBody)
-	}
-	// 处理multipart/formdata
-	if req.Files != nil && len(req.Files) > 0 {
-		// 实例化multipart
-		body := &bytes.Buffer{}
-		writer := multipart.NewWriter(body)
-
-		// 处理r.Data中的字符串参数
-		if req.Data != nil && len(req.Data) > 0 {
-			for key, value := range req.Data {
-				_ = writer.WriteField(key, value)
-			}
-		}
-		// 处理r.Files中的文件路径
-		for key, filePath := range req.Files {
-			item, _ := writer.CreateFormFile(key, filepath.Base(filePath))
-			file, _ := os.Open(filePath)
-			defer file.Close()
-			_, err := io.Copy(item, file)
-			if err != nil {
-				fmt.Println("复制文件内容失败")
-			}
-		}
-		// 关闭writer
-		err := writer.Close()
+		reqBody, err = req.encodeText(req.Raw)
 		if err != nil {
-			fmt.Println("关闭writer出错")
+			return nil, err
 		}
-		req.Headers["Content-Type"] = writer.FormDataContentType()
-		return body
+		return strings.NewReader(reqBody), nil
+	}
+	// 处理multipart/formdata
+	if len(req.Files) > 0 || len(req.Parts) > 0 {
+		return req.getMultipart()
 	}
 
 	// 处理application/x-www-form-urlencoded
-	if req.Data != nil && len(req.Data) > 0 {
-		urlValues := url.Values{}
-		for key, value := range req.Data {
-			urlValues.Add(key, value)
+	if len(req.Data) > 0 || len(req.DataValues) > 0 {
+		reqBody, err = req.encodeForm(req.formValues())
+		if err != nil {
+			return nil, err
 		}
-		reqBody = urlValues.Encode()
 		req.Headers["Content-Type"] = "application/x-www-form-urlencoded"
-		return strings.NewReader(reqBody)
+		if req.RequestCharset != "" {
+			req.Headers["Content-Type"] += "; charset=" + req.RequestCharset
+		}
+		return strings.NewReader(reqBody), nil
 	}
 
 	// 处理application/json
-	if req.Json != "" {
-		//bytesData, _ := json.Marshal(req.Json)
-		reqBody = req.Json
+	if req.Json != "" || req.JsonValue != nil {
+		reqBody, err = req.jsonBody()
+		if err != nil {
+			return nil, err
+		}
 		req.Headers["Content-Type"] = "application/json"
-		return strings.NewReader(reqBody)
+		return strings.NewReader(reqBody), nil
 	}
 
-	return strings.NewReader(reqBody)
+	return strings.NewReader(reqBody), nil
 }
 
 // 添加请求头-需要在getData后使用
@@ -289,6 +624,11 @@ func (req *Request) addHeaders(r *http.Request) {
 			r.Header.Add(key, value)
 		}
 	}
+	for key, values := range req.HeaderValues {
+		for _, value := range values {
+			r.Header.Add(key, value)
+		}
+	}
 }
 
 // 添加Cookies
@@ -310,33 +650,70 @@ func (req *Request) setAuth(r *http.Request) {
 }
 
 // 准备请求
-func (req *Request) prepare() *http.Request {
+func (req *Request) prepare(ctx context.Context) (*http.Request, error) {
 	req.handleConfig()
 	Method := req.getMethod()
-	Url := req.getUrl()
-	Data := req.getData()
-	r, err := http.NewRequest(Method, Url, Data)
+	Url, err := req.getUrl()
+	if err != nil {
+		return nil, req.newError(ErrInvalidUrl, err)
+	}
+	if err := req.checkCharset(); err != nil {
+		return nil, req.newError(ErrBuildBody, err)
+	}
+	Data, err := req.getData()
 	if err != nil {
-		fmt.Printf("构造请求失败: %s\n", err)
+		return nil, req.newError(ErrBuildBody, err)
+	}
+	r, err := http.NewRequestWithContext(ctx, Method, Url, Data)
+	if err != nil {
+		if closer, ok := Data.(io.Closer); ok {
+			closer.Close()
+		}
+		return nil, req.newError(ErrInvalidUrl, err)
+	}
+	if stream, ok := Data.(*streamBody); ok {
+		stream.apply(r)
 	}
 	req.addHeaders(r)
+	if err := req.compressBody(r); err != nil {
+		r.Body.Close()
+		return nil, req.newError(ErrBuildBody, err)
+	}
 	req.addCookies(r)
 	req.setAuth(r)
-	return r
+	if err := req.setCredentials(r); err != nil {
+		if r.Body != nil {
+			r.Body.Close()
+		}
+		return nil, req.newError(ErrAuth, err)
+	}
+	setAcceptEncoding(r)
+	if err := req.sign(r); err != nil {
+		if r.Body != nil {
+			r.Body.Close()
+		}
+		return nil, req.newError(ErrBuildBody, err)
+	}
+	req.meterUpload(r) // 签名计算请求数据摘要时不计入上传进度
+	return r, nil
 }
 
 // 组装响应对象
-func (req *Request) buildResponse(res *http.Response, elapsed float64) Response {
-	var resp Response
-	resBody, err := ioutil.ReadAll(res.Body)
+func (req *Request) buildResponse(res *http.Response, elapsed float64) (*Response, error) {
+	resp := &Response{Request: req}
+	req.meterDownload(res)
+	raw, err := req.decompress(res)
 	if err != nil {
-		fmt.Printf("读取响应数据失败: %s\n", err)
+		return nil, req.newError(ErrReadBody, err)
 	}
-	resp.Content = resBody
-	resp.Text = string(resBody)
+	resp.RawContent = raw
 	resp.StatusCode = res.StatusCode
-	resp.Reason = strings.Split(res.Status, " ")[1]
+	resp.Reason = http.StatusText(res.StatusCode)
+	if status := strings.SplitN(res.Status, " ", 2); len(status) == 2 {
+		resp.Reason = status[1]
+	}
 	resp.Elapsed = elapsed
+	resp.ContentLength = res.ContentLength
 	resp.Headers = map[string]string{}
 	for key, value := range res.Header {
 		resp.Headers[key] = strings.Join(value, ";")
@@ -345,32 +722,25 @@ func (req *Request) buildResponse(res *http.Response, elapsed float64) Response
 	for _, item := range res.Cookies() {
 		resp.Cookies[item.Name] = item.Value
 	}
-	return resp
-}
-
-func (req *Request) getClient() *http.Client {
-	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: req.NoVerify}, // 是否跳过验服务端证书
+	// 流式响应不读取响应数据, 由调用方读取并关闭Body
+	if req.Stream {
+		resp.Body = res.Body
+		return resp, nil
 	}
-	// 处理Proxy
-	if req.Proxy != "" {
-		proxy, err := url.Parse(req.Proxy)
-		if err != nil {
-			fmt.Printf("解析代理地址 \"%s\" 出错: %s\n", req.Proxy, err)
-		}
-		transport.Proxy = http.ProxyURL(proxy)
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, req.newError(ErrReadBody, err)
 	}
+	resp.setContent(resBody)
+	return resp, nil
+}
 
-	// 处理是否HTTP2
-	if req.HTTP2 == true {
-		err := http2.ConfigureTransport(transport)
-		if err != nil {
-			fmt.Printf("HTTP2传输配置出错: %s\n", err)
-		}
-		//transport.AllowHTTP = true
+func (req *Request) getClient() (*http.Client, error) {
+	transport, err := req.getTransport()
+	if err != nil {
+		return nil, err
 	}
-
-	client := &http.Client{Transport: transport}
+	client := &http.Client{Transport: transport, Jar: req.jar}
 	if req.Timeout > 0 {
 		client.Timeout = time.Duration(req.Timeout) * time.Millisecond
 	}
@@ -378,38 +748,60 @@ func (req *Request) getClient() *http.Client {
 		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
 			return http.ErrUseLastResponse
 		}
+	} else {
+		client.CheckRedirect = req.checkRedirect
 	}
-	return client
+	return client, nil
 }
 
-// Send 发送请求
+// Send 发送请求, 出错时打印错误并返回空响应, 需要处理错误时请使用 Do
 func (req *Request) Send() *Response {
-	r := req.prepare()
-	client := req.getClient()
-	start := time.Now()
-	res, err := client.Do(r)
+	return req.SendContext(context.Background())
+}
+
+// SendContext 使用ctx发送请求, ctx取消或超时时请求随之中断
+func (req *Request) SendContext(ctx context.Context) *Response {
+	resp, err := req.DoContext(ctx)
 	if err != nil {
-		fmt.Printf("发送请求失败: %s\n", err)
+		fmt.Printf("%s\n", err)
+		return &Response{Request: req}
 	}
-	defer res.Body.Close()
-	elapsed := time.Since(start).Seconds()
-	Wait.Add(1)
-	resp := req.buildResponse(res, elapsed)
-	Ch <- resp
-	Wait.Done()
+	return resp
+}
 
-	//// 处理Set-Cookies 修改 Request
-	//if resp.Cookies != nil {
-	//	req.SetCookies(resp.Cookies)
-	//}
+// Do 发送请求, 返回响应及错误
+func (req *Request) Do() (*Response, error) {
+	return req.DoContext(context.Background())
+}
 
-	return &resp
+// DoContext 使用ctx发送请求, 返回响应及错误, ctx的取消及截止时间作用于连接、TLS握手、重定向及读取响应
+func (req *Request) DoContext(ctx context.Context) (*Response, error) {
+	r, err := req.prepare(ctx)
+	if err != nil {
+		return nil, err
+	}
+	client, err := req.getClient()
+	if err != nil {
+		if r.Body != nil {
+			r.Body.Close() // 关闭已打开的文件及multipart、压缩管道
+		}
+		return nil, req.newError(ErrTransport, err)
+	}
+	return req.send(client, r)
 }
 
-// AsyncSend 发送异步请求
+// AsyncSend 发送异步请求, 响应通过 Ch 获取, 可使用 Wait.Wait() 等待全部异步请求完成
 func (req *Request) AsyncSend() {
-	go req.Send()
-	Wait.Wait()
+	req.AsyncSendContext(context.Background())
+}
+
+// AsyncSendContext 使用ctx发送异步请求, ctx取消后请求中断并向 Ch 发送空响应
+func (req *Request) AsyncSendContext(ctx context.Context) {
+	Wait.Add(1)
+	go func() {
+		defer Wait.Done()
+		Ch <- *req.SendContext(ctx)
+	}()
 }
 
 // GetRequestFromJson 从JSON字符串得到Request结构体
@@ -1,8 +1,13 @@
 package go_requests
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 // 发送GET 请求
@@ -210,3 +215,45 @@ func TestRequestWithPrevResponseCookies(t *testing.T) {
 	fmt.Printf("响应时间: %f秒\n", resp2.Elapsed)
 	fmt.Printf("响应文本: %s\n", resp2.Text)
 }
+
+// 测试ctx取消及截止时间
+func TestRequestDoContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.(http.Flusher).Flush()
+		select { // 先返回响应头, 响应体等待请求取消
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err := NewRequest("GET", server.URL).DoContext(ctx)
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("期望超时错误, 实际 %v", err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	_, err = NewRequest("GET", server.URL).DoContext(ctx)
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, ErrTransport) {
+		t.Fatalf("期望取消错误, 实际 %v", err)
+	}
+}
+
+// 测试使用ctx发送异步请求
+func TestAsyncSendContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	r := NewRequest("GET", server.URL)
+	r.AsyncSendContext(context.Background())
+	resp := <-Ch
+	Wait.Wait()
+	if resp.Text != "ok" {
+		t.Fatalf("响应不正确: %s", resp.Text)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/tidwall/gjson"
+	"io"
 )
 
 // Response 响应结构体
@@ -16,6 +17,20 @@ type Response struct {
 	Headers    map[string]string `json:"headers"`     // 响应头
 	Cookies    map[string]string `json:"cookies"`     // 响应Cookies
 	Request    *Request          `json:"request"`     // 原始请求
+	Attempts   int               `json:"attempts"`    // 请求尝试次数, 含重试
+	Charset    string            `json:"charset"`     // 响应文本的原始字符集, Text已转为UTF-8
+
+	ContentLength int64         `json:"content_length"` // 响应数据长度, 未知或已解压时为-1
+	RawContent    []byte        `json:"raw_content"`    // 压缩的原始响应数据, 仅在请求设置KeepRawContent且响应已压缩时可用
+	Body          io.ReadCloser `json:"-"`              // 流式响应数据, 仅在请求设置Stream时可用, 需调用Close关闭
+}
+
+// Close 关闭流式响应数据
+func (res *Response) Close() error {
+	if res.Body == nil {
+		return nil
+	}
+	return res.Body.Close()
 }
 
 func (res *Response) Json() map[string]interface{} {
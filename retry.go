@@ -0,0 +1,210 @@
+package go_requests
+
+import (
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	defaultRetryStatusCodes = []int{429, 502, 503, 504}
+	defaultRetryErrors      = []error{ErrTransport, ErrTimeout}
+)
+
+// RetryPolicy 重试策略
+type RetryPolicy struct {
+	MaxAttempts        int     `json:"max_attempts"`         // 最大尝试次数(含首次请求), 小于2时不重试
+	Backoff            int     `json:"backoff"`              // 首次重试前等待时间, 之后按指数增长, 单位 毫秒, 默认100
+	MaxBackoff         int     `json:"max_backoff"`          // 最大等待时间, 单位 毫秒, 默认10000
+	Jitter             float64 `json:"jitter"`               // 等待时间随机减少的最大比例, 取值0~1, 默认不抖动
+	StatusCodes        []int   `json:"status_codes"`         // 需要重试的状态码, 默认 429、502、503、504
+	Errors             []error `json:"-"`                    // 需要重试的错误类型, 默认 ErrTransport 及 ErrTimeout
+	IgnoreRetryAfter   bool    `json:"ignore_retry_after"`   // 忽略429及503响应的Retry-After头
+	AllowNonIdempotent bool    `json:"allow_non_idempotent"` // 允许重试POST、PATCH等非幂等请求, 默认仅重试幂等请求
+}
+
+// NewRetryPolicy 创建重试策略, maxAttempts为最大尝试次数(含首次请求)
+func NewRetryPolicy(maxAttempts int) *RetryPolicy {
+	return &RetryPolicy{MaxAttempts: maxAttempts}
+}
+
+func (policy *RetryPolicy) SetBackoff(backoff, maxBackoff int) *RetryPolicy {
+	policy.Backoff = backoff
+	policy.MaxBackoff = maxBackoff
+	return policy
+}
+
+func (policy *RetryPolicy) SetJitter(jitter float64) *RetryPolicy {
+	policy.Jitter = jitter
+	return policy
+}
+
+func (policy *RetryPolicy) SetStatusCodes(statusCodes ...int) *RetryPolicy {
+	policy.StatusCodes = statusCodes
+	return policy
+}
+
+func (policy *RetryPolicy) SetErrors(errs ...error) *RetryPolicy {
+	policy.Errors = errs
+	return policy
+}
+
+func (policy *RetryPolicy) SetAllowNonIdempotent(enable bool) *RetryPolicy {
+	policy.AllowNonIdempotent = enable
+	return policy
+}
+
+// 幂等请求方法
+func isIdempotent(method string) bool {
+	switch method {
+	case "GET", "HEAD", "OPTIONS", "TRACE", "PUT", "DELETE":
+		return true
+	}
+	return false
+}
+
+// 最大等待时间
+func (policy *RetryPolicy) maxBackoff() time.Duration {
+	if policy.MaxBackoff <= 0 {
+		return 10 * time.Second
+	}
+	return time.Duration(policy.MaxBackoff) * time.Millisecond
+}
+
+// 计算第attempt次请求后的等待时间
+func (policy *RetryPolicy) backoff(attempt int) time.Duration {
+	wait := 100 * time.Millisecond
+	if policy.Backoff > 0 {
+		wait = time.Duration(policy.Backoff) * time.Millisecond
+	}
+	for i := 1; i < attempt && wait < policy.maxBackoff(); i++ {
+		wait *= 2
+	}
+	if wait > policy.maxBackoff() {
+		wait = policy.maxBackoff()
+	}
+	if policy.Jitter > 0 {
+		wait -= time.Duration(rand.Float64() * policy.Jitter * float64(wait))
+	}
+	return wait
+}
+
+// 解析Retry-After头, 支持秒数及HTTP日期两种格式
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+// 判断第attempt次请求后是否需要重试及重试前等待时间
+func (policy *RetryPolicy) next(attempt int, method string, resp *Response, err error) (time.Duration, bool) {
+	if policy == nil || attempt >= policy.MaxAttempts {
+		return 0, false
+	}
+	if !policy.AllowNonIdempotent && !isIdempotent(method) {
+		return 0, false
+	}
+	if err != nil {
+		retryErrors := policy.Errors
+		if retryErrors == nil {
+			retryErrors = defaultRetryErrors
+		}
+		for _, kind := range retryErrors {
+			if errors.Is(err, kind) {
+				return policy.backoff(attempt), true
+			}
+		}
+		return 0, false
+	}
+	statusCodes := policy.StatusCodes
+	if statusCodes == nil {
+		statusCodes = defaultRetryStatusCodes
+	}
+	for _, statusCode := range statusCodes {
+		if resp.StatusCode != statusCode {
+			continue
+		}
+		wait := policy.backoff(attempt)
+		if !policy.IgnoreRetryAfter && (statusCode == 429 || statusCode == 503) {
+			if retryAfter, ok := parseRetryAfter(resp.Headers["Retry-After"]); ok {
+				if retryAfter > policy.maxBackoff() {
+					return 0, false // 服务端要求等待时间过长, 放弃重试
+				}
+				wait = retryAfter
+			}
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+// 发送请求, 按重试策略重试, 重试前通过GetBody重新获取请求数据
+func (req *Request) send(client *http.Client, r *http.Request) (*Response, error) {
+	ctx := r.Context()
+	for attempt := 1; ; attempt++ {
+		resp, err := req.sendOnce(client, r)
+		if resp != nil {
+			resp.Attempts = attempt
+		}
+		wait, retry := req.Retry.next(attempt, r.Method, resp, err)
+		if !retry || ctx.Err() != nil {
+			return resp, err
+		}
+		replay, ok := rewindRequest(r)
+		if !ok {
+			return resp, err // 请求数据无法重复读取
+		}
+		if req.sign(replay) != nil {
+			if replay.Body != nil {
+				replay.Body.Close()
+			}
+			return resp, err
+		}
+		r = replay
+		if resp != nil {
+			resp.Close() // 丢弃流式响应数据
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			if r.Body != nil {
+				r.Body.Close()
+			}
+			return nil, req.newError(ErrTransport, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// 发送一次请求并读取响应
+func (req *Request) sendOnce(client *http.Client, r *http.Request) (*Response, error) {
+	start := time.Now()
+	res, err := req.doAuth(client, r)
+	var reqErr *Error
+	if errors.As(err, &reqErr) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, req.newError(ErrTransport, err)
+	}
+	elapsed := time.Since(start).Seconds()
+	resp, err := req.buildResponse(res, elapsed)
+	if !req.Stream || err != nil {
+		res.Body.Close()
+	}
+	return resp, err
+}
@@ -0,0 +1,114 @@
+package go_requests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// 测试状态码重试及multipart请求数据重放
+func TestRetryMultipart(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if err := r.ParseMultipartForm(1 << 20); err != nil || r.FormValue("name") != "Kevin" || r.MultipartForm.File["pic"] == nil {
+			t.Errorf("第%d次请求数据不正确: %v", attempts, err)
+		}
+		if attempts < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	r := NewRequest("POST", server.URL).
+		SetFormData(map[string]string{"name": "Kevin"}).
+		SetUploadFiles(map[string]string{"pic": "./testdata/logo.png"}).
+		SetRetry(NewRetryPolicy(3).SetBackoff(1, 10).SetAllowNonIdempotent(true))
+	resp, err := r.Do()
+	if err != nil {
+		t.Fatalf("请求失败: %s", err)
+	}
+	if resp.StatusCode != 200 || resp.Attempts != 3 {
+		t.Fatalf("状态码 %d, 尝试次数 %d", resp.StatusCode, resp.Attempts)
+	}
+}
+
+// 测试默认不重试非幂等请求
+func TestRetryNonIdempotent(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	config := NewConfig().SetRetry(NewRetryPolicy(3).SetBackoff(1, 10))
+	resp, err := NewRequestWithConfig(config, "POST", server.URL).SetRawData("data").Do()
+	if err != nil || resp.Attempts != 1 || attempts != 1 {
+		t.Fatalf("POST请求不应重试: %v, 尝试%d次", err, attempts)
+	}
+	resp, err = NewRequestWithConfig(config, "GET", server.URL).Do()
+	if err != nil || resp.StatusCode != 503 || resp.Attempts != 3 {
+		t.Fatalf("GET请求应重试: %v", err)
+	}
+}
+
+// 测试Retry-After
+func TestRetryAfter(t *testing.T) {
+	var last time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if last.IsZero() {
+			last = time.Now()
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		if time.Since(last) < time.Second {
+			t.Errorf("未按Retry-After等待: %s", time.Since(last))
+		}
+	}))
+	defer server.Close()
+
+	resp, err := NewRequest("GET", server.URL).SetRetry(NewRetryPolicy(2).SetBackoff(1, 2000)).Do()
+	if err != nil || resp.StatusCode != 200 || resp.Attempts != 2 {
+		t.Fatalf("请求失败: %v", err)
+	}
+
+	// 超过最大等待时间时放弃重试
+	last = time.Time{}
+	resp, err = NewRequest("GET", server.URL).SetRetry(NewRetryPolicy(2).SetBackoff(1, 500)).Do()
+	if err != nil || resp.StatusCode != 429 || resp.Attempts != 1 {
+		t.Fatalf("不应重试: %v", err)
+	}
+}
+
+// 测试连接错误重试
+func TestRetryTransportError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	_, err := NewRequest("GET", server.URL).SetRetry(NewRetryPolicy(3).SetBackoff(1, 10)).Do()
+	if err == nil {
+		t.Fatal("期望连接错误")
+	}
+}
+
+// 测试指数退避及抖动
+func TestRetryBackoff(t *testing.T) {
+	policy := NewRetryPolicy(10).SetBackoff(100, 1000)
+	expected := []time.Duration{100, 200, 400, 800, 1000, 1000}
+	for i, wait := range expected {
+		if got := policy.backoff(i + 1); got != wait*time.Millisecond {
+			t.Errorf("第%d次等待 %s, 期望 %s", i+1, got, wait*time.Millisecond)
+		}
+	}
+	policy.SetJitter(0.5)
+	for i := 0; i < 100; i++ {
+		if got := policy.backoff(1); got < 50*time.Millisecond || got > 100*time.Millisecond {
+			t.Fatalf("抖动超出范围: %s", got)
+		}
+	}
+}
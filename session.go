@@ -1,62 +1,205 @@
 package go_requests
 
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
 type Session struct {
-	Config  *Config `json:"config"` // 请求配置
-	cookies map[string]string
+	Config      *Config     `json:"config"`       // 请求配置
+	Jar         *CookieJar  `json:"-"`            // Cookie存储, 会话中的所有请求及重定向共享
+	BearerToken string      `json:"bearer_token"` // 会话Bearer Token, 未设置Authorization请求头的请求自动携带
+	DigestAuth  *DigestAuth `json:"-"`            // 会话Digest摘要认证, 会话中的请求复用服务器nonce
+	OAuth2      *OAuth2     `json:"oauth2"`       // 会话OAuth2认证, 会话中的请求共享令牌, 令牌随会话保存
+
+	Authenticator Authenticator `json:"-"` // 会话认证方式, 会话中的请求共享
 }
 
 func NewSession(config *Config) *Session {
-	return &Session{Config: config}
+	return &Session{Config: config, Jar: NewCookieJar()}
 }
 
-func (s *Session) SendRequest(req *Request) *Response {
-	if s.cookies != nil {
-		updateMap(req.Cookies, s.cookies)
+// SetBearerToken 设置会话Bearer Token, 可随会话保存
+func (s *Session) SetBearerToken(token string) *Session {
+	s.BearerToken = token
+	return s
+}
+
+// SetDigestAuth 设置会话Digest摘要认证, 未设置DigestAuth的请求自动使用
+func (s *Session) SetDigestAuth(username, password string) *Session {
+	s.DigestAuth = NewDigestAuth(username, password)
+	return s
+}
+
+// SetAuthenticator 设置会话认证方式, 未设置Authenticator的请求自动使用
+func (s *Session) SetAuthenticator(auth Authenticator) *Session {
+	s.Authenticator = auth
+	return s
+}
+
+// SetOAuth2 设置会话OAuth2认证, 未设置OAuth2的请求自动使用
+func (s *Session) SetOAuth2(oauth2 *OAuth2) *Session {
+	s.OAuth2 = oauth2
+	return s
+}
+
+// Cookies 获取会话中将发送到URL的Cookies
+func (s *Session) Cookies(rawUrl string) ([]*http.Cookie, error) {
+	u, err := url.Parse(rawUrl)
+	if err != nil || s.Jar == nil {
+		return nil, err
 	}
-	resp := req.Send()
-	if resp.Cookies != nil {
-		updateMap(s.cookies, resp.Cookies)
-		//s.cookies = resp.Cookies // TODO Merge cookies
+	return s.Jar.Cookies(u), nil
+}
+
+// SetCookies 按URL向会话添加Cookies, 未设置Domain及Path时按URL计算
+func (s *Session) SetCookies(rawUrl string, cookies ...*http.Cookie) error {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return err
+	}
+	if s.Jar == nil {
+		s.Jar = NewCookieJar()
+	}
+	s.Jar.SetCookies(u, cookies)
+	return nil
+}
+
+// ClearCookies 删除会话中将发送到URL的Cookies
+func (s *Session) ClearCookies(rawUrl string) error {
+	u, err := url.Parse(rawUrl)
+	if err != nil || s.Jar == nil {
+		return err
+	}
+	s.Jar.ClearURL(u)
+	return nil
+}
+
+// ClearAllCookies 删除会话中的全部Cookies
+func (s *Session) ClearAllCookies() {
+	if s.Jar != nil {
+		s.Jar.Clear()
+	}
+}
+
+// 构造会话请求, data不为空时作为Raw请求数据, 复制headers以免会话认证信息写入调用方的map
+func (s *Session) newRequest(method, url, data string, headers map[string]string) *Request {
+	req := NewRequestWithConfig(s.Config, method, url).
+		SetHeaders(copyMap(headers))
+	if data != "" {
+		req.SetRawData(data)
+	}
+	return req
+}
+
+// SendRequest 使用会话发送请求, 出错时打印错误并返回空响应
+func (s *Session) SendRequest(req *Request) *Response {
+	resp, err := s.Do(req)
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		return &Response{Request: req}
 	}
 	return resp
 }
 
+// Do 使用会话发送请求, 返回响应及错误
+func (s *Session) Do(req *Request) (*Response, error) {
+	return s.DoContext(context.Background(), req)
+}
+
+// DoContext 使用会话及ctx发送请求, 返回响应及错误, 请求及重定向均使用会话Cookie存储
+func (s *Session) DoContext(ctx context.Context, req *Request) (*Response, error) {
+	if s.Jar != nil {
+		req.jar = s.Jar
+	}
+	if _, ok := req.Headers["Authorization"]; s.BearerToken != "" && !ok {
+		req.Headers = copyMap(req.Headers) // 不修改调用方传入的请求头map
+		req.SetBearerToken(s.BearerToken)
+	}
+	if s.DigestAuth != nil && req.DigestAuth == nil {
+		req.DigestAuth = s.DigestAuth
+	}
+	if s.OAuth2 != nil && req.OAuth2 == nil {
+		req.OAuth2 = s.OAuth2
+	}
+	if s.Authenticator != nil && req.Authenticator == nil {
+		req.Authenticator = s.Authenticator
+	}
+	return req.DoContext(ctx)
+}
+
 func (s *Session) Get(url string, headers map[string]string) *Response {
-	req := NewRequestWithConfig(s.Config, "GET", url).
-		SetHeaders(headers)
-	return s.SendRequest(req)
+	return s.SendRequest(s.newRequest("GET", url, "", headers))
 }
 
 func (s *Session) Post(url, data string, headers map[string]string) *Response {
-	req := NewRequestWithConfig(s.Config, "POST", url).
-		SetRawData(data).
-		SetHeaders(headers)
-	return req.Send()
+	return s.SendRequest(s.newRequest("POST", url, data, headers))
 }
 
 func (s *Session) Put(url, data string, headers map[string]string) *Response {
-	req := NewRequestWithConfig(s.Config, "Put", url).
-		SetRawData(data).
-		SetHeaders(headers)
-	return req.Send()
+	return s.SendRequest(s.newRequest("PUT", url, data, headers))
 }
 
 func (s *Session) Delete(url string, headers map[string]string) *Response {
-	req := NewRequestWithConfig(s.Config, "DELETE", url).
-		SetHeaders(headers)
-	return s.SendRequest(req)
+	return s.SendRequest(s.newRequest("DELETE", url, "", headers))
 }
 
 func (s *Session) Head(url string, headers map[string]string) *Response {
-	req := NewRequestWithConfig(s.Config, "HEAD", url).
-		SetHeaders(headers)
-	return s.SendRequest(req)
+	return s.SendRequest(s.newRequest("HEAD", url, "", headers))
 }
 
 func (s *Session) Options(url string, headers map[string]string) *Response {
-	req := NewRequestWithConfig(s.Config, "OPTIONS", url).
-		SetHeaders(headers)
-	return s.SendRequest(req)
+	return s.SendRequest(s.newRequest("OPTIONS", url, "", headers))
+}
+
+func (s *Session) DoGet(url string, headers map[string]string) (*Response, error) {
+	return s.Do(s.newRequest("GET", url, "", headers))
+}
+
+func (s *Session) DoPost(url, data string, headers map[string]string) (*Response, error) {
+	return s.Do(s.newRequest("POST", url, data, headers))
+}
+
+func (s *Session) DoPut(url, data string, headers map[string]string) (*Response, error) {
+	return s.Do(s.newRequest("PUT", url, data, headers))
+}
+
+func (s *Session) DoDelete(url string, headers map[string]string) (*Response, error) {
+	return s.Do(s.newRequest("DELETE", url, "", headers))
+}
+
+func (s *Session) DoHead(url string, headers map[string]string) (*Response, error) {
+	return s.Do(s.newRequest("HEAD", url, "", headers))
+}
+
+func (s *Session) DoOptions(url string, headers map[string]string) (*Response, error) {
+	return s.Do(s.newRequest("OPTIONS", url, "", headers))
+}
+
+func (s *Session) GetContext(ctx context.Context, url string, headers map[string]string) (*Response, error) {
+	return s.DoContext(ctx, s.newRequest("GET", url, "", headers))
+}
+
+func (s *Session) PostContext(ctx context.Context, url, data string, headers map[string]string) (*Response, error) {
+	return s.DoContext(ctx, s.newRequest("POST", url, data, headers))
+}
+
+func (s *Session) PutContext(ctx context.Context, url, data string, headers map[string]string) (*Response, error) {
+	return s.DoContext(ctx, s.newRequest("PUT", url, data, headers))
+}
+
+func (s *Session) DeleteContext(ctx context.Context, url string, headers map[string]string) (*Response, error) {
+	return s.DoContext(ctx, s.newRequest("DELETE", url, "", headers))
+}
+
+func (s *Session) HeadContext(ctx context.Context, url string, headers map[string]string) (*Response, error) {
+	return s.DoContext(ctx, s.newRequest("HEAD", url, "", headers))
+}
+
+func (s *Session) OptionsContext(ctx context.Context, url string, headers map[string]string) (*Response, error) {
+	return s.DoContext(ctx, s.newRequest("OPTIONS", url, "", headers))
 }
 
 func Get(url string, headers map[string]string) *Response {
@@ -68,11 +211,11 @@ func Post(url, data string, headers map[string]string) *Response {
 }
 
 func Put(url, data string, headers map[string]string) *Response {
-	return NewSession(nil).Post(url, data, headers)
+	return NewSession(nil).Put(url, data, headers)
 }
 
 func Delete(url string, headers map[string]string) *Response {
-	return NewSession(nil).Get(url, headers)
+	return NewSession(nil).Delete(url, headers)
 }
 
 func Head(url string, headers map[string]string) *Response {
@@ -82,3 +225,51 @@ func Head(url string, headers map[string]string) *Response {
 func Options(url string, headers map[string]string) *Response {
 	return NewSession(nil).Options(url, headers)
 }
+
+func DoGet(url string, headers map[string]string) (*Response, error) {
+	return NewSession(nil).DoGet(url, headers)
+}
+
+func DoPost(url, data string, headers map[string]string) (*Response, error) {
+	return NewSession(nil).DoPost(url, data, headers)
+}
+
+func DoPut(url, data string, headers map[string]string) (*Response, error) {
+	return NewSession(nil).DoPut(url, data, headers)
+}
+
+func DoDelete(url string, headers map[string]string) (*Response, error) {
+	return NewSession(nil).DoDelete(url, headers)
+}
+
+func DoHead(url string, headers map[string]string) (*Response, error) {
+	return NewSession(nil).DoHead(url, headers)
+}
+
+func DoOptions(url string, headers map[string]string) (*Response, error) {
+	return NewSession(nil).DoOptions(url, headers)
+}
+
+func GetContext(ctx context.Context, url string, headers map[string]string) (*Response, error) {
+	return NewSession(nil).GetContext(ctx, url, headers)
+}
+
+func PostContext(ctx context.Context, url, data string, headers map[string]string) (*Response, error) {
+	return NewSession(nil).PostContext(ctx, url, data, headers)
+}
+
+func PutContext(ctx context.Context, url, data string, headers map[string]string) (*Response, error) {
+	return NewSession(nil).PutContext(ctx, url, data, headers)
+}
+
+func DeleteContext(ctx context.Context, url string, headers map[string]string) (*Response, error) {
+	return NewSession(nil).DeleteContext(ctx, url, headers)
+}
+
+func HeadContext(ctx context.Context, url string, headers map[string]string) (*Response, error) {
+	return NewSession(nil).HeadContext(ctx, url, headers)
+}
+
+func OptionsContext(ctx context.Context, url string, headers map[string]string) (*Response, error) {
+	return NewSession(nil).OptionsContext(ctx, url, headers)
+}
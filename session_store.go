@@ -0,0 +1,131 @@
+package go_requests
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 会话保存文件内容
+type sessionState struct {
+	Config      *Config   `json:"config"`       // 会话请求配置
+	Cookies     []*Cookie `json:"cookies"`      // 会话Cookies
+	BearerToken string    `json:"bearer_token"` // 会话Bearer Token
+	OAuth2      *OAuth2   `json:"oauth2"`       // 会话OAuth2认证及缓存的令牌
+}
+
+// Save 将会话配置、Cookies、Token及OAuth2令牌保存为JSON文件, 文件权限为0600
+func (s *Session) Save(path string) error {
+	state := sessionState{Config: s.Config, BearerToken: s.BearerToken, OAuth2: s.OAuth2}
+	if s.Jar != nil {
+		state.Cookies = s.Jar.All()
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+// LoadSession 从Save保存的JSON文件恢复会话
+func LoadSession(path string) (*Session, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var state sessionState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("解析会话文件出错: %w", err)
+	}
+	s := NewSession(state.Config)
+	s.BearerToken = state.BearerToken
+	s.OAuth2 = state.OAuth2
+	s.Jar.Add(state.Cookies...)
+	return s, nil
+}
+
+// SaveCookies 将会话Cookies保存为Netscape格式(cookies.txt), 可供curl及wget使用
+func (s *Session) SaveCookies(path string) error {
+	var builder strings.Builder
+	builder.WriteString("# Netscape HTTP Cookie File\n")
+	if s.Jar != nil {
+		for _, cookie := range s.Jar.All() {
+			domain := cookie.Domain
+			if !cookie.HostOnly {
+				domain = "." + domain
+			}
+			if cookie.HttpOnly {
+				domain = "#HttpOnly_" + domain
+			}
+			var expires int64
+			if !cookie.Expires.IsZero() {
+				expires = cookie.Expires.Unix()
+			}
+			fmt.Fprintf(&builder, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n", domain, netscapeBool(!cookie.HostOnly),
+				cookie.Path, netscapeBool(cookie.Secure), expires, cookie.Name, cookie.Value)
+		}
+	}
+	return ioutil.WriteFile(path, []byte(builder.String()), 0600)
+}
+
+// LoadCookies 从Netscape格式(cookies.txt)文件加载Cookies到会话
+func (s *Session) LoadCookies(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	var cookies []*Cookie
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		httpOnly := strings.HasPrefix(line, "#HttpOnly_")
+		if httpOnly {
+			line = strings.TrimPrefix(line, "#HttpOnly_")
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			return fmt.Errorf("Cookie文件第%d行格式错误", lineNo)
+		}
+		expires, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return fmt.Errorf("Cookie文件第%d行过期时间错误: %w", lineNo, err)
+		}
+		cookie := &Cookie{
+			Domain:   fields[0],
+			HostOnly: fields[1] != "TRUE",
+			Path:     fields[2],
+			Secure:   fields[3] == "TRUE",
+			Name:     fields[5],
+			Value:    fields[6],
+			HttpOnly: httpOnly,
+		}
+		if expires > 0 {
+			cookie.Expires = time.Unix(expires, 0)
+		}
+		cookies = append(cookies, cookie)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if s.Jar == nil {
+		s.Jar = NewCookieJar()
+	}
+	s.Jar.Add(cookies...)
+	return nil
+}
+
+func netscapeBool(value bool) string {
+	if value {
+		return "TRUE"
+	}
+	return "FALSE"
+}
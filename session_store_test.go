@@ -0,0 +1,103 @@
+package go_requests
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newSessionStoreServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "sid", Value: "abc", Path: "/", HttpOnly: true, Expires: time.Now().Add(time.Hour)})
+			http.SetCookie(w, &http.Cookie{Name: "lang", Value: "zh", Path: "/"})
+			return
+		}
+		sid, _ := r.Cookie("sid")
+		lang, _ := r.Cookie("lang")
+		if sid == nil || lang == nil {
+			return
+		}
+		_, _ = w.Write([]byte(sid.Value + "," + lang.Value + "," + r.Header.Get("Authorization") + "," + r.Header.Get("Token")))
+	}))
+}
+
+// 测试保存及恢复会话
+func TestSessionSaveAndLoad(t *testing.T) {
+	server := newSessionStoreServer()
+	defer server.Close()
+	path := filepath.Join(t.TempDir(), "session.json")
+
+	config := NewConfig().SetBaseUrl(server.URL).SetHeaders(map[string]string{"Token": "123"})
+	s := NewSession(config).SetBearerToken("xyz")
+	s.Get("/login", nil)
+	if err := s.Save(path); err != nil {
+		t.Fatalf("保存会话失败: %s", err)
+	}
+
+	loaded, err := LoadSession(path)
+	if err != nil {
+		t.Fatalf("恢复会话失败: %s", err)
+	}
+	resp := loaded.Get("/home", nil)
+	if resp.Text != "abc,zh,Bearer xyz,123" {
+		t.Fatalf("恢复的会话不正确: %s", resp.Text)
+	}
+}
+
+// 测试Netscape格式Cookie文件
+func TestSessionSaveAndLoadCookies(t *testing.T) {
+	server := newSessionStoreServer()
+	defer server.Close()
+	path := filepath.Join(t.TempDir(), "cookies.txt")
+
+	s := NewSession(nil)
+	s.Get(server.URL+"/login", nil)
+	if err := s.SaveCookies(path); err != nil {
+		t.Fatalf("保存Cookies失败: %s", err)
+	}
+	data, _ := ioutil.ReadFile(path)
+	if !strings.Contains(string(data), "#HttpOnly_127.0.0.1\tFALSE\t/\tFALSE\t") {
+		t.Fatalf("Cookie文件格式不正确:\n%s", data)
+	}
+
+	loaded := NewSession(nil)
+	if err := loaded.LoadCookies(path); err != nil {
+		t.Fatalf("加载Cookies失败: %s", err)
+	}
+	if resp := loaded.Get(server.URL+"/home", nil); resp.Text != "abc,zh,," {
+		t.Fatalf("加载的Cookies不正确: %s", resp.Text)
+	}
+
+	_ = ioutil.WriteFile(path, []byte("example.com\tFALSE\t/\n"), 0600)
+	if err := loaded.LoadCookies(path); err == nil {
+		t.Fatal("期望Cookie文件格式错误")
+	}
+}
+
+// 测试会话的BearerToken不写入调用方的请求头map
+func TestSessionBearerTokenHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer server.Close()
+
+	s := NewSession(nil).SetBearerToken("xyz")
+	headers := map[string]string{"Accept": "text/plain"}
+	if resp, err := s.DoGet(server.URL, headers); err != nil || resp.Text != "Bearer xyz" {
+		t.Fatalf("期望携带会话BearerToken, 实际 %v %v", resp, err)
+	}
+	if _, err := s.DoPost(server.URL, "data", headers); err != nil {
+		t.Fatalf("请求失败: %s", err)
+	}
+	if _, err := s.Do(NewRequest("GET", server.URL).SetHeaders(headers)); err != nil {
+		t.Fatalf("请求失败: %s", err)
+	}
+	if len(headers) != 1 || headers["Authorization"] != "" {
+		t.Errorf("调用方的请求头map不应被修改: %v", headers)
+	}
+}
@@ -1,7 +1,10 @@
 package go_requests
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
@@ -61,3 +64,66 @@ func TestKeepCookies(t *testing.T) {
 	fmt.Printf("响应文本: %s\n", resp2.Text)
 
 }
+
+// 测试会话使用ctx发送请求
+func TestSessionGetContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Method))
+	}))
+	defer server.Close()
+
+	s := NewSession(nil)
+	resp, err := s.GetContext(context.Background(), server.URL, nil)
+	if err != nil || resp.Text != "GET" {
+		t.Fatalf("请求失败: %v", err)
+	}
+	resp, err = PutContext(context.Background(), server.URL, "data", nil)
+	if err != nil || resp.Text != "PUT" {
+		t.Fatalf("请求失败: %v", err)
+	}
+}
+
+// 测试会话在请求及重定向之间保持Cookies
+func TestSessionCookies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "sid", Value: "abc", Path: "/"})
+			http.Redirect(w, r, "/home", http.StatusFound)
+		case "/logout":
+			http.SetCookie(w, &http.Cookie{Name: "sid", Path: "/", MaxAge: -1})
+		default:
+			if cookie, err := r.Cookie("sid"); err == nil {
+				_, _ = w.Write([]byte(cookie.Value))
+			}
+		}
+	}))
+	defer server.Close()
+
+	s := NewSession(nil)
+	resp := s.Post(server.URL+"/login", "name=Kevin", nil)
+	if resp.Text != "abc" {
+		t.Fatalf("重定向未携带Cookie: %s", resp.Text)
+	}
+	if resp = s.Get(server.URL+"/home", nil); resp.Text != "abc" {
+		t.Fatalf("后续请求未携带Cookie: %s", resp.Text)
+	}
+	cookies, _ := s.Cookies(server.URL)
+	if len(cookies) != 1 || cookies[0].Value != "abc" {
+		t.Fatalf("获取Cookie失败: %v", cookies)
+	}
+
+	s.Get(server.URL+"/logout", nil)
+	if resp = s.Get(server.URL+"/home", nil); resp.Text != "" {
+		t.Fatalf("Cookie未删除: %s", resp.Text)
+	}
+
+	_ = s.SetCookies(server.URL, &http.Cookie{Name: "sid", Value: "manual"})
+	if resp = s.Get(server.URL+"/home", nil); resp.Text != "manual" {
+		t.Fatalf("手动添加Cookie失败: %s", resp.Text)
+	}
+	_ = s.ClearCookies(server.URL)
+	if resp = s.Get(server.URL+"/home", nil); resp.Text != "" {
+		t.Fatalf("Cookie未清除: %s", resp.Text)
+	}
+}
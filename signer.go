@@ -0,0 +1,395 @@
+package go_requests
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Signer 请求签名, 在请求头组装完成后调用, 重试及认证重发时重新签名
+type Signer interface {
+	Sign(r *http.Request) error
+}
+
+// SignerFunc 函数形式的Signer
+type SignerFunc func(r *http.Request) error
+
+func (f SignerFunc) Sign(r *http.Request) error {
+	return f(r)
+}
+
+// 使用Signer为请求签名
+func (req *Request) sign(r *http.Request) error {
+	if req.Signer == nil {
+		return nil
+	}
+	return req.Signer.Sign(r)
+}
+
+// 计算请求数据摘要, 请求数据无法重复读取时返回false
+func hashBody(r *http.Request, h hash.Hash) (bool, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return true, nil
+	}
+	if r.GetBody == nil {
+		return false, nil
+	}
+	body, err := r.GetBody()
+	if err != nil {
+		return false, err
+	}
+	defer body.Close()
+	_, err = io.Copy(h, body)
+	return err == nil, err
+}
+
+func hmacSum(newHash func() hash.Hash, key []byte, data string) []byte {
+	mac := hmac.New(newHash, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// 按RFC 3986编码, 仅保留非保留字符
+func awsEscape(s string) string {
+	return strings.Replace(url.QueryEscape(s), "+", "%20", -1)
+}
+
+// 排序并编码Query参数
+func canonicalQuery(query url.Values) string {
+	var pairs []string
+	for key, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, awsEscape(key)+"="+awsEscape(value))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+const (
+	awsAlgorithm       = "AWS4-HMAC-SHA256"
+	awsTimeFormat      = "20060102T150405Z"
+	awsUnsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+// 不参与AWS签名的请求头
+var awsIgnoredHeaders = map[string]bool{"Authorization": true, "User-Agent": true, "X-Amzn-Trace-Id": true, "Expect": true}
+
+// AWSV4Signer AWS Signature Version 4签名, 支持请求头签名及预签名URL, 可用于S3及兼容S3的对象存储
+// 请求数据可重复读取时计算SHA256摘要, 否则使用UNSIGNED-PAYLOAD
+type AWSV4Signer struct {
+	AccessKey       string           `json:"access_key"`       // 访问密钥ID
+	SecretKey       string           `json:"secret_key"`       // 访问密钥
+	SessionToken    string           `json:"session_token"`    // 临时凭证的会话令牌
+	Region          string           `json:"region"`           // 区域，如 us-east-1
+	Service         string           `json:"service"`          // 服务名称，如 s3
+	Presign         bool             `json:"presign"`          // 签名放在URL Query参数中
+	Expires         int              `json:"expires"`          // 预签名URL有效期，单位 秒，默认900秒
+	UnsignedPayload bool             `json:"unsigned_payload"` // 不计算请求数据摘要
+	Now             func() time.Time `json:"-"`                // 当前时间，默认 time.Now
+}
+
+func NewAWSV4Signer(accessKey, secretKey, region, service string) *AWSV4Signer {
+	return &AWSV4Signer{AccessKey: accessKey, SecretKey: secretKey, Region: region, Service: service}
+}
+
+// Sign 计算签名并添加X-Amz-Date及Authorization请求头, Presign时添加到Query参数
+func (s *AWSV4Signer) Sign(r *http.Request) error {
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+	t := now().UTC()
+	amzDate := t.Format(awsTimeFormat)
+	scope := strings.Join([]string{t.Format("20060102"), s.Region, s.Service, "aws4_request"}, "/")
+
+	payloadHash, err := s.payloadHash(r)
+	if err != nil {
+		return fmt.Errorf("计算请求数据摘要出错: %w", err)
+	}
+	if s.Presign {
+		r.Header.Del("Authorization")
+	} else {
+		r.Header.Set("X-Amz-Date", amzDate)
+		if s.SessionToken != "" {
+			r.Header.Set("X-Amz-Security-Token", s.SessionToken)
+		}
+		if s.Service == "s3" {
+			r.Header.Set("X-Amz-Content-Sha256", payloadHash)
+		}
+	}
+	headers, signedHeaders := s.canonicalHeaders(r)
+	query := r.URL.Query()
+	if s.Presign {
+		expires := s.Expires
+		if expires <= 0 {
+			expires = 900
+		}
+		for _, key := range []string{"X-Amz-Algorithm", "X-Amz-Credential", "X-Amz-Date", "X-Amz-Expires", "X-Amz-SignedHeaders", "X-Amz-Security-Token", "X-Amz-Signature"} {
+			query.Del(key)
+		}
+		query.Set("X-Amz-Algorithm", awsAlgorithm)
+		query.Set("X-Amz-Credential", s.AccessKey+"/"+scope)
+		query.Set("X-Amz-Date", amzDate)
+		query.Set("X-Amz-Expires", strconv.Itoa(expires))
+		query.Set("X-Amz-SignedHeaders", signedHeaders)
+		if s.SessionToken != "" {
+			query.Set("X-Amz-Security-Token", s.SessionToken)
+		}
+	}
+	canonicalRequest := strings.Join([]string{
+		r.Method,
+		s.canonicalPath(r.URL),
+		canonicalQuery(query),
+		headers,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{awsAlgorithm, amzDate, scope, hex.EncodeToString(requestHash[:])}, "\n")
+
+	key := []byte("AWS4" + s.SecretKey)
+	for _, item := range []string{t.Format("20060102"), s.Region, s.Service, "aws4_request"} {
+		key = hmacSum(sha256.New, key, item)
+	}
+	signature := hex.EncodeToString(hmacSum(sha256.New, key, stringToSign))
+
+	if s.Presign {
+		r.URL.RawQuery = canonicalQuery(query) + "&X-Amz-Signature=" + signature
+		return nil
+	}
+	r.URL.RawQuery = canonicalQuery(query)
+	r.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		awsAlgorithm, s.AccessKey, scope, signedHeaders, signature))
+	return nil
+}
+
+// PresignURL 生成预签名URL, 可直接分享给其他客户端使用
+func (s *AWSV4Signer) PresignURL(method, rawUrl string) (string, error) {
+	r, err := http.NewRequest(method, rawUrl, nil)
+	if err != nil {
+		return "", err
+	}
+	presigner := *s
+	presigner.Presign = true
+	if err := presigner.Sign(r); err != nil {
+		return "", err
+	}
+	return r.URL.String(), nil
+}
+
+// 请求数据摘要, 预签名URL、UnsignedPayload及请求数据无法重复读取时为UNSIGNED-PAYLOAD
+func (s *AWSV4Signer) payloadHash(r *http.Request) (string, error) {
+	if s.Presign || s.UnsignedPayload {
+		return awsUnsignedPayload, nil
+	}
+	h := sha256.New()
+	ok, err := hashBody(r, h)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return awsUnsignedPayload, nil
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// 编码URL路径, S3以外的服务需要对每段路径编码两次
+func (s *AWSV4Signer) canonicalPath(u *url.URL) string {
+	path := u.Path
+	if path == "" {
+		return "/"
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segment = awsEscape(segment)
+		if s.Service != "s3" {
+			segment = awsEscape(segment)
+		}
+		segments[i] = segment
+	}
+	return strings.Join(segments, "/")
+}
+
+// 规范化请求头, 返回请求头字符串及参与签名的请求头名称
+// 预签名URL只对Host签名
+func (s *AWSV4Signer) canonicalHeaders(r *http.Request) (string, string) {
+	host := r.Host
+	if host == "" {
+		host = r.URL.Host
+	}
+	values := map[string]string{"host": host}
+	if !s.Presign {
+		for key, list := range r.Header {
+			if awsIgnoredHeaders[http.CanonicalHeaderKey(key)] {
+				continue
+			}
+			items := make([]string, len(list))
+			for i, item := range list {
+				items[i] = strings.Join(strings.Fields(item), " ")
+			}
+			values[strings.ToLower(key)] = strings.Join(items, ",")
+		}
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var builder strings.Builder
+	for _, name := range names {
+		builder.WriteString(name + ":" + values[name] + "\n")
+	}
+	return builder.String(), strings.Join(names, ";")
+}
+
+// HMAC签名支持的摘要算法
+var hmacHashes = map[string]func() hash.Hash{
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+	"md5":    md5.New,
+}
+
+var placeholderPattern = regexp.MustCompile(`\{([a-z_0-9]+)(?::([A-Za-z0-9-]+))?\}`)
+
+// HMACSigner 通用HMAC签名, 按模板拼接待签名字符串, 签名及时间戳、随机数通过请求头发送
+// 模板支持的占位符: {method} {host} {path} {query}(排序后的Query参数) {timestamp} {nonce} {key_id}
+// {body_sha256} {body_md5}(请求数据摘要, 十六进制) {header:名称}(请求头的值)
+type HMACSigner struct {
+	KeyId           string           `json:"key_id"`           // 密钥ID，通过{key_id}引用
+	Key             string           `json:"key"`              // 签名密钥
+	Algorithm       string           `json:"algorithm"`        // 摘要算法，sha1、sha256、sha512或md5，默认sha256
+	Encoding        string           `json:"encoding"`         // 签名编码，hex或base64，默认hex
+	Template        string           `json:"template"`         // 待签名字符串模板，默认 "{method}\n{path}\n{query}\n{timestamp}\n{nonce}\n{body_sha256}"
+	SignatureHeader string           `json:"signature_header"` // 签名请求头，默认 X-Signature
+	SignatureFormat string           `json:"signature_format"` // 签名请求头格式，支持{signature}及{key_id}，默认 "{signature}"
+	TimestampHeader string           `json:"timestamp_header"` // 时间戳请求头，默认 X-Timestamp，为"-"时不发送
+	TimestampFormat string           `json:"timestamp_format"` // 时间戳格式，unix、unix_ms或rfc3339，默认unix
+	NonceHeader     string           `json:"nonce_header"`     // 随机数请求头，默认 X-Nonce，为"-"时不发送
+	Now             func() time.Time `json:"-"`                // 当前时间，默认 time.Now
+}
+
+const defaultHMACTemplate = "{method}\n{path}\n{query}\n{timestamp}\n{nonce}\n{body_sha256}"
+
+func NewHMACSigner(keyId, key string) *HMACSigner {
+	return &HMACSigner{KeyId: keyId, Key: key}
+}
+
+// Sign 计算签名并设置签名、时间戳及随机数请求头
+func (s *HMACSigner) Sign(r *http.Request) error {
+	newHash, ok := hmacHashes[strings.ToLower(withDefault(s.Algorithm, "sha256"))]
+	if !ok {
+		return fmt.Errorf("不支持的HMAC算法 \"%s\"", s.Algorithm)
+	}
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+	var timestamp string
+	switch strings.ToLower(withDefault(s.TimestampFormat, "unix")) {
+	case "unix":
+		timestamp = strconv.FormatInt(now().Unix(), 10)
+	case "unix_ms":
+		timestamp = strconv.FormatInt(now().UnixNano()/int64(time.Millisecond), 10)
+	case "rfc3339":
+		timestamp = now().UTC().Format(time.RFC3339)
+	default:
+		return fmt.Errorf("不支持的时间戳格式 \"%s\"", s.TimestampFormat)
+	}
+	nonce, err := newCnonce()
+	if err != nil {
+		return err
+	}
+	if name := withDefault(s.TimestampHeader, "X-Timestamp"); name != "-" {
+		r.Header.Set(name, timestamp)
+	}
+	if name := withDefault(s.NonceHeader, "X-Nonce"); name != "-" {
+		r.Header.Set(name, nonce)
+	}
+
+	var renderErr error
+	message := placeholderPattern.ReplaceAllStringFunc(withDefault(s.Template, defaultHMACTemplate), func(placeholder string) string {
+		match := placeholderPattern.FindStringSubmatch(placeholder)
+		switch match[1] {
+		case "method":
+			return r.Method
+		case "host":
+			if r.Host != "" {
+				return r.Host
+			}
+			return r.URL.Host
+		case "path":
+			return r.URL.EscapedPath()
+		case "query":
+			return canonicalQuery(r.URL.Query())
+		case "timestamp":
+			return timestamp
+		case "nonce":
+			return nonce
+		case "key_id":
+			return s.KeyId
+		case "header":
+			return r.Header.Get(match[2])
+		case "body_sha256", "body_md5":
+			h := sha256.New()
+			if match[1] == "body_md5" {
+				h = md5.New()
+			}
+			ok, err := hashBody(r, h)
+			if err == nil && !ok {
+				err = errors.New("请求数据无法重复读取")
+			}
+			if err != nil {
+				if renderErr == nil {
+					renderErr = fmt.Errorf("计算请求数据摘要出错: %w", err)
+				}
+				return ""
+			}
+			return hex.EncodeToString(h.Sum(nil))
+		}
+		if renderErr == nil {
+			renderErr = fmt.Errorf("签名模板中不支持的占位符 %s", placeholder)
+		}
+		return ""
+	})
+	if renderErr != nil {
+		return renderErr
+	}
+
+	sum := hmacSum(newHash, []byte(s.Key), message)
+	var signature string
+	switch strings.ToLower(withDefault(s.Encoding, "hex")) {
+	case "hex":
+		signature = hex.EncodeToString(sum)
+	case "base64":
+		signature = base64.StdEncoding.EncodeToString(sum)
+	default:
+		return fmt.Errorf("不支持的签名编码 \"%s\"", s.Encoding)
+	}
+	value := strings.NewReplacer("{signature}", signature, "{key_id}", s.KeyId).Replace(withDefault(s.SignatureFormat, "{signature}"))
+	r.Header.Set(withDefault(s.SignatureHeader, "X-Signature"), value)
+	return nil
+}
+
+func withDefault(value, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,215 @@
+package go_requests
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"golang.org/x/crypto/pkcs12"
+	"io/ioutil"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// TLSConfig TLS配置, 支持客户端证书(mTLS)及自定义CA证书
+type TLSConfig struct {
+	CertFile       string   `json:"cert_file"`       // 客户端证书文件(PEM)
+	KeyFile        string   `json:"key_file"`        // 客户端私钥文件(PEM)
+	CertPEM        string   `json:"cert_pem"`        // 客户端证书内容(PEM)
+	KeyPEM         string   `json:"key_pem"`         // 客户端私钥内容(PEM)
+	PKCS12File     string   `json:"pkcs12_file"`     // 客户端PKCS#12证书包文件(.p12/.pfx)
+	PKCS12Data     []byte   `json:"pkcs12_data"`     // 客户端PKCS#12证书包内容, JSON中为base64编码
+	PKCS12Password string   `json:"pkcs12_password"` // PKCS#12证书包密码
+	CAFiles        []string `json:"ca_files"`        // 额外信任的CA证书文件(PEM)
+	CAPEM          string   `json:"ca_pem"`          // 额外信任的CA证书内容(PEM)
+	ReplaceCAs     bool     `json:"replace_cas"`     // 仅信任以上CA证书, 不使用系统根证书
+	ServerName     string   `json:"server_name"`     // 覆盖SNI及证书校验使用的主机名
+	MinVersion     string   `json:"min_version"`     // 最低TLS版本, 可选 "1.0"、"1.1"、"1.2"、"1.3"
+	MaxVersion     string   `json:"max_version"`     // 最高TLS版本
+}
+
+func NewTLSConfig() *TLSConfig {
+	return &TLSConfig{}
+}
+
+// SetClientCert 设置客户端证书及私钥文件
+func (c *TLSConfig) SetClientCert(certFile, keyFile string) *TLSConfig {
+	c.CertFile = certFile
+	c.KeyFile = keyFile
+	return c
+}
+
+// SetClientCertPEM 设置内存中的客户端证书及私钥
+func (c *TLSConfig) SetClientCertPEM(certPEM, keyPEM string) *TLSConfig {
+	c.CertPEM = certPEM
+	c.KeyPEM = keyPEM
+	return c
+}
+
+// SetPKCS12 设置客户端PKCS#12证书包文件及密码
+func (c *TLSConfig) SetPKCS12(pkcs12File, password string) *TLSConfig {
+	c.PKCS12File = pkcs12File
+	c.PKCS12Password = password
+	return c
+}
+
+// SetPKCS12Data 设置内存中的客户端PKCS#12证书包及密码
+func (c *TLSConfig) SetPKCS12Data(data []byte, password string) *TLSConfig {
+	c.PKCS12Data = data
+	c.PKCS12Password = password
+	return c
+}
+
+// AddCAFile 添加信任的CA证书文件
+func (c *TLSConfig) AddCAFile(caFile string) *TLSConfig {
+	c.CAFiles = append(c.CAFiles, caFile)
+	return c
+}
+
+// SetCAPEM 设置内存中信任的CA证书
+func (c *TLSConfig) SetCAPEM(caPEM string) *TLSConfig {
+	c.CAPEM = caPEM
+	return c
+}
+
+// SetReplaceCAs 仅信任配置的CA证书, 不使用系统根证书
+func (c *TLSConfig) SetReplaceCAs(enable bool) *TLSConfig {
+	c.ReplaceCAs = enable
+	return c
+}
+
+func (c *TLSConfig) SetServerName(serverName string) *TLSConfig {
+	c.ServerName = serverName
+	return c
+}
+
+// SetVersions 设置TLS最低及最高版本, 为空时不限制
+func (c *TLSConfig) SetVersions(minVersion, maxVersion string) *TLSConfig {
+	c.MinVersion = minVersion
+	c.MaxVersion = maxVersion
+	return c
+}
+
+// 配置指纹, 用于区分连接池中的 Transport
+func (c *TLSConfig) fingerprint() string {
+	if c == nil {
+		return ""
+	}
+	data, _ := json.Marshal(c)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// 解析TLS版本
+func parseTLSVersion(version string) (uint16, error) {
+	if version == "" {
+		return 0, nil
+	}
+	if value, ok := tlsVersions[version]; ok {
+		return value, nil
+	}
+	return 0, fmt.Errorf("不支持的TLS版本 \"%s\"", version)
+}
+
+// 加载客户端证书
+func (c *TLSConfig) loadCertificates() ([]tls.Certificate, error) {
+	var certs []tls.Certificate
+	if c.CertFile != "" || c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("加载客户端证书出错: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+	if c.CertPEM != "" || c.KeyPEM != "" {
+		cert, err := tls.X509KeyPair([]byte(c.CertPEM), []byte(c.KeyPEM))
+		if err != nil {
+			return nil, fmt.Errorf("解析客户端证书出错: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+	pkcs12Data := c.PKCS12Data
+	if c.PKCS12File != "" {
+		data, err := ioutil.ReadFile(c.PKCS12File)
+		if err != nil {
+			return nil, fmt.Errorf("读取PKCS#12证书包出错: %w", err)
+		}
+		pkcs12Data = data
+	}
+	if len(pkcs12Data) > 0 {
+		blocks, err := pkcs12.ToPEM(pkcs12Data, c.PKCS12Password)
+		if err != nil {
+			return nil, fmt.Errorf("解析PKCS#12证书包出错: %w", err)
+		}
+		var pemData []byte
+		for _, block := range blocks {
+			pemData = append(pemData, pem.EncodeToMemory(block)...)
+		}
+		cert, err := tls.X509KeyPair(pemData, pemData)
+		if err != nil {
+			return nil, fmt.Errorf("解析PKCS#12证书包出错: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+	return certs, nil
+}
+
+// 加载信任的CA证书, 未配置时返回nil使用系统根证书
+func (c *TLSConfig) loadRootCAs() (*x509.CertPool, error) {
+	if len(c.CAFiles) == 0 && c.CAPEM == "" {
+		if c.ReplaceCAs {
+			return x509.NewCertPool(), nil
+		}
+		return nil, nil
+	}
+	pool := x509.NewCertPool()
+	if !c.ReplaceCAs {
+		if systemPool, err := x509.SystemCertPool(); err == nil {
+			pool = systemPool
+		}
+	}
+	for _, caFile := range c.CAFiles {
+		data, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取CA证书出错: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("CA证书 \"%s\" 中没有有效的PEM证书", caFile)
+		}
+	}
+	if c.CAPEM != "" && !pool.AppendCertsFromPEM([]byte(c.CAPEM)) {
+		return nil, fmt.Errorf("CA证书内容中没有有效的PEM证书")
+	}
+	return pool, nil
+}
+
+// 组装 tls.Config
+func (c *TLSConfig) build(noVerify bool) (*tls.Config, error) {
+	config := &tls.Config{InsecureSkipVerify: noVerify} // 是否跳过验服务端证书
+	if c == nil {
+		return config, nil
+	}
+	var err error
+	if config.Certificates, err = c.loadCertificates(); err != nil {
+		return nil, err
+	}
+	if config.RootCAs, err = c.loadRootCAs(); err != nil {
+		return nil, err
+	}
+	if config.MinVersion, err = parseTLSVersion(c.MinVersion); err != nil {
+		return nil, err
+	}
+	if config.MaxVersion, err = parseTLSVersion(c.MaxVersion); err != nil {
+		return nil, err
+	}
+	config.ServerName = c.ServerName
+	return config, nil
+}
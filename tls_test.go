@@ -0,0 +1,77 @@
+package go_requests
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// 启动要求客户端证书的HTTPS服务, 返回服务及服务端证书PEM
+func newMutualTLSServer(t *testing.T) (*httptest.Server, string) {
+	caPEM, err := ioutil.ReadFile("./testdata/ca.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCAs := x509.NewCertPool()
+	clientCAs.AppendCertsFromPEM(caPEM)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	serverPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	return server, string(serverPEM)
+}
+
+// 测试客户端证书及自定义CA证书
+func TestRequestWithClientCert(t *testing.T) {
+	server, serverPEM := newMutualTLSServer(t)
+	defer server.Close()
+	certPEM, _ := ioutil.ReadFile("./testdata/client.pem")
+	keyPEM, _ := ioutil.ReadFile("./testdata/client.key")
+
+	cases := map[string]*TLSConfig{
+		"PEM文件":   NewTLSConfig().SetClientCert("./testdata/client.pem", "./testdata/client.key"),
+		"PEM内容":   NewTLSConfig().SetClientCertPEM(string(certPEM), string(keyPEM)),
+		"PKCS#12": NewTLSConfig().SetPKCS12("./testdata/client.p12", "123456"),
+	}
+	for name, tlsConfig := range cases {
+		tlsConfig.SetCAPEM(serverPEM).SetReplaceCAs(true).SetServerName("example.com").SetVersions("1.2", "1.3")
+		resp, err := NewRequest("GET", server.URL).SetTLSConfig(tlsConfig).Do()
+		if err != nil {
+			t.Fatalf("%s: 请求失败: %s", name, err)
+		}
+		if resp.Text != "client" {
+			t.Fatalf("%s: 服务端未收到客户端证书: %s", name, resp.Text)
+		}
+	}
+
+	// 不信任服务端证书
+	config := NewConfig().SetTLSConfig(NewTLSConfig().SetClientCert("./testdata/client.pem", "./testdata/client.key"))
+	if _, err := NewRequestWithConfig(config, "GET", server.URL).Do(); err == nil {
+		t.Fatal("期望证书校验失败")
+	}
+	// 未提供客户端证书
+	if _, err := NewRequest("GET", server.URL).SetNoVerify(true).Do(); err == nil {
+		t.Fatal("期望握手失败")
+	}
+}
+
+// 测试TLS配置错误
+func TestTLSConfigErrors(t *testing.T) {
+	cases := map[string]*TLSConfig{
+		"证书文件不存在":     NewTLSConfig().SetClientCert("./testdata/missing.pem", "./testdata/client.key"),
+		"PKCS#12密码错误": NewTLSConfig().SetPKCS12("./testdata/client.p12", "wrong"),
+		"CA证书无效":      NewTLSConfig().SetCAPEM("invalid"),
+		"TLS版本无效":     NewTLSConfig().SetVersions("1.4", ""),
+	}
+	for name, tlsConfig := range cases {
+		if _, err := tlsConfig.build(false); err == nil {
+			t.Errorf("%s: 期望出错", name)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package go_requests
+
+import (
+	"fmt"
+	"golang.org/x/net/http2"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	defaultTransports = &transportPool{} // 未使用Config时共享的连接池
+	poolMu            sync.Mutex
+)
+
+// 区分连接池中 http.Transport 的配置, 配置相同的请求复用同一个 Transport
+type transportKey struct {
+	noVerify            bool
+	tls                 string // TLSConfig指纹
+	proxy               string // ProxyConfig指纹
+	http2               bool
+	maxIdleConns        int
+	maxIdleConnsPerHost int
+	maxConnsPerHost     int
+	idleConnTimeout     int
+	unixSocket          string
+	configDial          bool // 使用Config中的DialContext, 每个Config有独立的连接池
+	localAddr           string
+	preferIP            string
+}
+
+// 连接池, 按TLS、代理及HTTP2配置缓存 http.Transport 以复用keep-alive连接
+type transportPool struct {
+	mu         sync.Mutex
+	transports map[transportKey]*http.Transport
+}
+
+// 获取配置对应的 Transport, 不存在时使用build新建
+func (pool *transportPool) get(key transportKey, build func() (*http.Transport, error)) (*http.Transport, error) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	if transport, ok := pool.transports[key]; ok {
+		return transport, nil
+	}
+	transport, err := build()
+	if err != nil {
+		return nil, err
+	}
+	if pool.transports == nil {
+		pool.transports = map[transportKey]*http.Transport{}
+	}
+	pool.transports[key] = transport
+	return transport, nil
+}
+
+// 关闭连接池中所有空闲连接
+func (pool *transportPool) closeIdleConnections() {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	for _, transport := range pool.transports {
+		transport.CloseIdleConnections()
+	}
+}
+
+// 按请求配置新建 Transport, 未配置的连接池参数与 http.DefaultTransport 一致
+func (req *Request) newTransport(key transportKey) (*http.Transport, error) {
+	tlsConfig, err := req.TLS.build(req.NoVerify)
+	if err != nil {
+		return nil, err
+	}
+	dial, err := req.getDialer()
+	if err != nil {
+		return nil, err
+	}
+	transport := &http.Transport{
+		DialContext:           dial,
+		TLSClientConfig:       tlsConfig,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   key.maxIdleConnsPerHost,
+		MaxConnsPerHost:       key.maxConnsPerHost,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+	if key.maxIdleConns > 0 {
+		transport.MaxIdleConns = key.maxIdleConns
+	}
+	if key.idleConnTimeout > 0 {
+		transport.IdleConnTimeout = time.Duration(key.idleConnTimeout) * time.Millisecond
+	}
+	// 处理Proxy, 连接Unix socket时不使用代理
+	if conf := req.getProxyConfig(); conf != nil && req.UnixSocket == "" {
+		if err := conf.apply(transport, dial); err != nil {
+			return nil, err
+		}
+	}
+
+	// 处理是否HTTP2
+	if req.HTTP2 == true {
+		err := http2.ConfigureTransport(transport)
+		if err != nil {
+			return nil, fmt.Errorf("HTTP2传输配置出错: %w", err)
+		}
+		//transport.AllowHTTP = true
+	}
+	return transport, nil
+}
+
+// 获取Config的连接池
+func (conf *Config) pool() *transportPool {
+	poolMu.Lock()
+	defer poolMu.Unlock()
+	if conf.transports == nil {
+		conf.transports = &transportPool{}
+	}
+	return conf.transports
+}
+
+// CloseIdleConnections 关闭Config连接池中的空闲连接
+func (conf *Config) CloseIdleConnections() {
+	conf.pool().closeIdleConnections()
+}
+
+// CloseIdleConnections 关闭会话连接池中的空闲连接
+func (s *Session) CloseIdleConnections() {
+	if s.Config == nil {
+		defaultTransports.closeIdleConnections()
+		return
+	}
+	s.Config.CloseIdleConnections()
+}
+
+// 获取请求使用的 Transport, 使用Config时从Config的连接池获取, 否则使用全局共享连接池
+func (req *Request) getTransport() (*http.Transport, error) {
+	key := transportKey{noVerify: req.NoVerify, tls: req.TLS.fingerprint(), proxy: req.getProxyConfig().fingerprint(), http2: req.HTTP2,
+		unixSocket: req.UnixSocket, localAddr: req.LocalAddr, preferIP: req.PreferIP}
+	if req.DialContext != nil {
+		// 函数无法比较, 单个请求设置的DialContext使用独立的 Transport 且不保持连接
+		transport, err := req.newTransport(key)
+		if err != nil {
+			return nil, err
+		}
+		transport.DisableKeepAlives = true
+		return transport, nil
+	}
+	pool := defaultTransports
+	if conf := req.Config; conf != nil {
+		key.maxIdleConns = conf.MaxIdleConns
+		key.maxIdleConnsPerHost = conf.MaxIdleConnsPerHost
+		key.maxConnsPerHost = conf.MaxConnsPerHost
+		key.idleConnTimeout = conf.IdleConnTimeout
+		key.configDial = conf.DialContext != nil
+		pool = conf.pool()
+	}
+	return pool.get(key, func() (*http.Transport, error) {
+		return req.newTransport(key)
+	})
+}
@@ -0,0 +1,47 @@
+package go_requests
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// 测试连接池复用keep-alive连接
+func TestTransportReuse(t *testing.T) {
+	var conns int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	config := NewConfig().SetMaxIdleConns(10, 10).SetIdleConnTimeout(5000)
+	s := NewSession(config)
+	for i := 0; i < 5; i++ {
+		if _, err := s.DoGet(server.URL, nil); err != nil {
+			t.Fatalf("请求失败: %s", err)
+		}
+	}
+	if atomic.LoadInt32(&conns) != 1 {
+		t.Fatalf("期望复用1个连接, 实际新建%d个", conns)
+	}
+
+	// 不同配置使用不同的 Transport
+	t1, _ := NewRequestWithConfig(config, "GET", server.URL).getTransport()
+	t2, _ := NewRequestWithConfig(config, "GET", server.URL).SetNoVerify(true).getTransport()
+	t3, _ := NewRequestWithConfig(config, "GET", server.URL).getTransport()
+	if t1 == t2 || t1 != t3 {
+		t.Fatal("Transport缓存不正确")
+	}
+	if t1.MaxIdleConnsPerHost != 10 {
+		t.Fatalf("连接池配置未生效: %d", t1.MaxIdleConnsPerHost)
+	}
+	s.CloseIdleConnections()
+}
@@ -0,0 +1,147 @@
+package go_requests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// JSON请求文件中的多值字段, 值可以是字符串或字符串数组
+type multiValues map[string][]string
+
+func (values *multiValues) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*values = multiValues{}
+	for key, item := range raw {
+		var value string
+		if err := json.Unmarshal(item, &value); err == nil {
+			(*values)[key] = []string{value}
+			continue
+		}
+		var list []string
+		if err := json.Unmarshal(item, &list); err != nil {
+			return fmt.Errorf("\"%s\"的值必须是字符串或字符串数组", key)
+		}
+		(*values)[key] = list
+	}
+	return nil
+}
+
+// 将单个值存入single, 返回多个值的字段
+func (values multiValues) split(single map[string]string) map[string][]string {
+	multi := map[string][]string{}
+	for key, list := range values {
+		if len(list) == 1 {
+			single[key] = list[0]
+			continue
+		}
+		multi[key] = list
+	}
+	return multi
+}
+
+// UnmarshalJSON 解析JSON请求, params、headers及data中的值可以是字符串或字符串数组, json可以是字符串或任意JSON值
+func (req *Request) UnmarshalJSON(data []byte) error {
+	type request Request
+	aux := struct {
+		*request
+		Params  multiValues `json:"params"`
+		Headers multiValues `json:"headers"`
+		Data    multiValues `json:"data"`
+		Json    *jsonField  `json:"json"`
+	}{request: (*request)(req)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if aux.Json != nil {
+		req.Json = string(*aux.Json)
+	}
+	if aux.Params != nil {
+		if req.Params == nil {
+			req.Params = map[string]string{}
+		}
+		for key, values := range aux.Params.split(req.Params) {
+			for _, value := range values {
+				req.AddParam(key, value)
+			}
+		}
+	}
+	if aux.Headers != nil {
+		if req.Headers == nil {
+			req.Headers = map[string]string{}
+		}
+		for key, values := range aux.Headers.split(req.Headers) {
+			for _, value := range values {
+				req.AddHeader(key, value)
+			}
+		}
+	}
+	if aux.Data != nil {
+		if req.Data == nil {
+			req.Data = map[string]string{}
+		}
+		for key, values := range aux.Data.split(req.Data) {
+			for _, value := range values {
+				req.AddFormData(key, value)
+			}
+		}
+	}
+	return nil
+}
+
+// 合并Config中的默认多值参数, 请求中已有的键不合并
+func mergeValues(values url.Values, defaults url.Values, exists map[string]string) url.Values {
+	for key, list := range defaults {
+		if _, ok := exists[key]; ok {
+			continue
+		}
+		if _, ok := values[key]; ok {
+			continue
+		}
+		if values == nil {
+			values = url.Values{}
+		}
+		values[key] = append([]string(nil), list...)
+	}
+	return values
+}
+
+// 合并Config中的默认多值请求头, 请求中已有的请求头不合并
+func mergeHeader(header http.Header, defaults http.Header, exists map[string]string) http.Header {
+	existsHeader := http.Header{}
+	for key := range exists {
+		existsHeader.Set(key, "")
+	}
+	for key, list := range defaults {
+		key = http.CanonicalHeaderKey(key)
+		if _, ok := existsHeader[key]; ok {
+			continue
+		}
+		if header.Get(key) != "" {
+			continue
+		}
+		if header == nil {
+			header = http.Header{}
+		}
+		header[key] = append([]string(nil), list...)
+	}
+	return header
+}
+
+// 表单数据, 合并Data及DataValues
+func (req *Request) formValues() url.Values {
+	urlValues := url.Values{}
+	for key, value := range req.Data {
+		urlValues.Add(key, value)
+	}
+	for key, values := range req.DataValues {
+		for _, value := range values {
+			urlValues.Add(key, value)
+		}
+	}
+	return urlValues
+}
@@ -0,0 +1,61 @@
+package go_requests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// 测试多值Query参数、请求头及表单数据
+func TestMultiValues(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		_, _ = w.Write([]byte(strings.Join([]string{
+			r.URL.RawQuery,
+			strings.Join(r.Header.Values("Accept"), ","),
+			strings.Join(r.PostForm["tag"], ","),
+		}, "|")))
+	}))
+	defer server.Close()
+
+	config := NewConfig().AddParam("token", "abc").AddHeader("Accept", "text/plain")
+	resp, err := NewRequestWithConfig(config, "POST", server.URL+"?page=1&tag=x").
+		SetParams(map[string]string{"page": "2"}).
+		AddParam("tag", "a").
+		AddParam("tag", "b").
+		AddHeader("Accept", "application/json").
+		AddHeader("Accept", "application/xml").
+		AddFormData("tag", "c").
+		AddFormData("tag", "d").
+		Do()
+	if err != nil {
+		t.Fatalf("请求失败: %s", err)
+	}
+	expected := "page=2&tag=x&tag=a&tag=b&token=abc|application/json,application/xml|c,d"
+	if resp.Text != expected {
+		t.Fatalf("期望 %s, 实际 %s", expected, resp.Text)
+	}
+}
+
+// 测试JSON请求中的多值字段
+func TestMultiValuesFromJson(t *testing.T) {
+	r := GetRequestFromJson([]byte(`{
+		"url": "https://httpbin.org/post",
+		"params": {"tag": ["a", "b"], "name": "Kevin"},
+		"headers": {"accept": ["text/html", "text/plain"]},
+		"data": {"age": "12", "hobby": ["read", "run"]}
+	}`))
+	if r.Params["name"] != "Kevin" || strings.Join(r.ParamValues["tag"], ",") != "a,b" {
+		t.Fatalf("Params解析错误: %v %v", r.Params, r.ParamValues)
+	}
+	if len(r.HeaderValues.Values("Accept")) != 2 {
+		t.Fatalf("Headers解析错误: %v", r.HeaderValues)
+	}
+	if r.Data["age"] != "12" || len(r.DataValues["hobby"]) != 2 || r.getMethod() != "POST" {
+		t.Fatalf("Data解析错误: %v %v", r.Data, r.DataValues)
+	}
+	if err := r.UnmarshalJSON([]byte(`{"params": {"age": 12}}`)); err == nil {
+		t.Fatal("期望解析出错")
+	}
+}